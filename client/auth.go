@@ -0,0 +1,128 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go/v4/option"
+)
+
+// AuthMode is the scheme used to authenticate with the Cloudflare API
+type AuthMode string
+
+const (
+	// AuthModeToken authenticates with a scoped API token
+	AuthModeToken AuthMode = "token"
+	// AuthModeGlobalKey authenticates with the account email and the legacy
+	// Global API Key
+	AuthModeGlobalKey AuthMode = "global_key"
+)
+
+// Credentials holds the secrets used to authenticate with the Cloudflare API
+type Credentials struct {
+	Email string
+	Token string
+	Key   string
+}
+
+// CredentialSource supplies the credentials used to create the Cloudflare
+// client. Sources must not log the credentials they return.
+type CredentialSource interface {
+	Credentials() (Credentials, error)
+}
+
+// CredentialSourceFunc adapts a function to a CredentialSource
+type CredentialSourceFunc func() (Credentials, error)
+
+// Credentials calls f
+func (f CredentialSourceFunc) Credentials() (Credentials, error) {
+	return f()
+}
+
+// EnvSource reads the credentials from the CLOUDFLARE_API_* environment
+// variables, or from the files named by their _FILE variants, such as
+// CLOUDFLARE_API_TOKEN_FILE, for secrets mounted as files
+type EnvSource struct{}
+
+// Credentials returns the credentials from the environment, or an error
+// wrapping ErrAuth if a variable is set along with its _FILE variant or a
+// file cannot be read
+func (EnvSource) Credentials() (Credentials, error) {
+	var credentials Credentials
+	var err error
+
+	if credentials.Email, err = envOrFile("CLOUDFLARE_API_EMAIL"); err != nil {
+		return Credentials{}, err
+	}
+	if credentials.Token, err = envOrFile("CLOUDFLARE_API_TOKEN"); err != nil {
+		return Credentials{}, err
+	}
+	if credentials.Key, err = envOrFile("CLOUDFLARE_API_KEY"); err != nil {
+		return Credentials{}, err
+	}
+	return credentials, nil
+}
+
+// envOrFile returns the value of the environment variable, or the contents
+// of the file named by its _FILE variant, with surrounding whitespace
+// trimmed. Errors name the variable and the file but never the contents.
+func envOrFile(name string) (string, error) {
+	value := strings.TrimSpace(os.Getenv(name))
+	path := strings.TrimSpace(os.Getenv(name + "_FILE"))
+
+	if path == "" {
+		return value, nil
+	}
+	if value != "" {
+		return "", fmt.Errorf("%w: set either %s or %s_FILE, not both", ErrAuth, name, name)
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to read %s_FILE: %w", ErrAuth, name, err)
+	}
+	return strings.TrimSpace(string(contents)), nil
+}
+
+// Mode returns the authentication scheme the credentials are meant for, or
+// an error wrapping ErrAuth if they are missing or mix both schemes. The
+// email is ignored with a token, as older setups passed both.
+func (c Credentials) Mode() (AuthMode, error) {
+	switch {
+	case c.Token != "" && c.Key != "":
+		return "", fmt.Errorf("%w: set either CLOUDFLARE_API_TOKEN or CLOUDFLARE_API_KEY, not both", ErrAuth)
+	case c.Token != "":
+		return AuthModeToken, nil
+	case c.Key != "" && c.Email == "":
+		return "", fmt.Errorf("%w: CLOUDFLARE_API_KEY requires CLOUDFLARE_API_EMAIL", ErrAuth)
+	case c.Key != "":
+		return AuthModeGlobalKey, nil
+	default:
+		return "", fmt.Errorf("%w: no credentials, set CLOUDFLARE_API_TOKEN, or CLOUDFLARE_API_EMAIL and CLOUDFLARE_API_KEY", ErrAuth)
+	}
+}
+
+// options returns the authentication mode and the SDK options
+// authenticating with the credentials. The headers of the other scheme are
+// removed, as the SDK also reads some credentials from the environment on its
+// own.
+func (c Credentials) options() (AuthMode, []option.RequestOption, error) {
+	mode, err := c.Mode()
+	if err != nil {
+		return "", nil, err
+	}
+
+	if mode == AuthModeGlobalKey {
+		return mode, []option.RequestOption{
+			option.WithHeaderDel("Authorization"),
+			option.WithAPIEmail(c.Email),
+			option.WithAPIKey(c.Key),
+		}, nil
+	}
+	return mode, []option.RequestOption{
+		option.WithHeaderDel("X-Auth-Email"),
+		option.WithHeaderDel("X-Auth-Key"),
+		option.WithAPIToken(c.Token),
+	}, nil
+}
@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudflare/cloudflare-go/v4"
+	"github.com/cloudflare/cloudflare-go/v4/option"
+)
+
+func TestCredentialsMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		credentials Credentials
+		mode        AuthMode
+		wantErr     bool
+	}{
+		{"should use a token", Credentials{Token: "token"}, AuthModeToken, false},
+		{"should ignore the email with a token", Credentials{Email: "user@example.com", Token: "token"}, AuthModeToken, false},
+		{"should use a global key with an email", Credentials{Email: "user@example.com", Key: "key"}, AuthModeGlobalKey, false},
+		{"should reject a global key without an email", Credentials{Key: "key"}, "", true},
+		{"should reject both a token and a key", Credentials{Email: "user@example.com", Token: "token", Key: "key"}, "", true},
+		{"should reject missing credentials", Credentials{Email: "user@example.com"}, "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mode, err := test.credentials.Mode()
+			if mode != test.mode {
+				t.Errorf("Mode() is incorrect, got: %q, want: %q", mode, test.mode)
+			}
+			if test.wantErr && !errors.Is(err, ErrAuth) {
+				t.Errorf("Expected ErrAuth, got: %v", err)
+			}
+			if !test.wantErr && err != nil {
+				t.Errorf("Mode() returned an error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCredentialsOptions(t *testing.T) {
+	// Credentials the SDK would pick up from the environment on its own
+	t.Setenv("CLOUDFLARE_EMAIL", "env@example.com")
+
+	tests := []struct {
+		name        string
+		credentials Credentials
+		want        map[string]string
+	}{
+		{
+			"should send only the token",
+			Credentials{Email: "user@example.com", Token: "token"},
+			map[string]string{"Authorization": "Bearer token", "X-Auth-Email": "", "X-Auth-Key": ""},
+		},
+		{
+			"should send only the email and global key",
+			Credentials{Email: "user@example.com", Key: "key"},
+			map[string]string{"Authorization": "", "X-Auth-Email": "user@example.com", "X-Auth-Key": "key"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var header http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header.Clone()
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintln(w, `{"result": [], "success": true, "errors": [], "messages": []}`)
+			}))
+			defer server.Close()
+
+			mode, opts, err := test.credentials.options()
+			if err != nil {
+				t.Fatalf("options() returned an error: %v", err)
+			}
+			if wantMode, _ := test.credentials.Mode(); mode != wantMode {
+				t.Errorf("Mode is incorrect, got: %s, want: %s", mode, wantMode)
+			}
+			api := cloudflare.NewClient(append(opts, option.WithBaseURL(server.URL), option.WithMaxRetries(0))...)
+
+			if _, err := NewCloudflare(api).GetZoneIDByName(context.Background(), "example.com"); !errors.Is(err, ErrZoneNotFound) {
+				t.Fatalf("Expected ErrZoneNotFound, got: %v", err)
+			}
+			for key, want := range test.want {
+				if got := header.Get(key); got != want {
+					t.Errorf("Header %s is incorrect, got: %q, want: %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEnvSource(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenFile, []byte("  file-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    Credentials
+		wantErr string
+	}{
+		{
+			name: "should read the variables",
+			env:  map[string]string{"CLOUDFLARE_API_EMAIL": " user@example.com ", "CLOUDFLARE_API_TOKEN": "token\n"},
+			want: Credentials{Email: "user@example.com", Token: "token"},
+		},
+		{
+			name: "should read a file and trim it",
+			env:  map[string]string{"CLOUDFLARE_API_TOKEN_FILE": tokenFile},
+			want: Credentials{Token: "file-token"},
+		},
+		{
+			name:    "should reject a variable set along with its file",
+			env:     map[string]string{"CLOUDFLARE_API_TOKEN": "token", "CLOUDFLARE_API_TOKEN_FILE": tokenFile},
+			wantErr: "set either CLOUDFLARE_API_TOKEN or CLOUDFLARE_API_TOKEN_FILE",
+		},
+		{
+			name:    "should report a missing file",
+			env:     map[string]string{"CLOUDFLARE_API_KEY_FILE": filepath.Join(dir, "missing")},
+			wantErr: "failed to read CLOUDFLARE_API_KEY_FILE",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, name := range []string{"CLOUDFLARE_API_EMAIL", "CLOUDFLARE_API_TOKEN", "CLOUDFLARE_API_KEY"} {
+				t.Setenv(name, test.env[name])
+				t.Setenv(name+"_FILE", test.env[name+"_FILE"])
+			}
+
+			credentials, err := EnvSource{}.Credentials()
+
+			if test.wantErr != "" {
+				if !errors.Is(err, ErrAuth) || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("Expected an error containing %q, got: %v", test.wantErr, err)
+				}
+				if err != nil && strings.Contains(err.Error(), "file-token") {
+					t.Errorf("Expected the error not to contain the credentials, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Credentials() returned an error: %v", err)
+			}
+			if credentials != test.want {
+				t.Errorf("Credentials() is incorrect, got: %+v, want: %+v", credentials, test.want)
+			}
+		})
+	}
+}
+
+func TestNewCloudflareFromSource(t *testing.T) {
+	t.Run("should use the mode of the credentials", func(t *testing.T) {
+		provider, err := NewCloudflareFromSource(CredentialSourceFunc(func() (Credentials, error) {
+			return Credentials{Email: "user@example.com", Key: "key"}, nil
+		}))
+		if err != nil {
+			t.Fatalf("NewCloudflareFromSource() returned an error: %v", err)
+		}
+		if provider.AuthMode() != AuthModeGlobalKey {
+			t.Errorf("AuthMode() is incorrect, got: %s, want: %s", provider.AuthMode(), AuthModeGlobalKey)
+		}
+	})
+
+	t.Run("should reject incomplete credentials", func(t *testing.T) {
+		_, err := NewCloudflareFromSource(CredentialSourceFunc(func() (Credentials, error) {
+			return Credentials{Key: "key"}, nil
+		}))
+		if !errors.Is(err, ErrAuth) {
+			t.Errorf("Expected an authentication error, got: %v", err)
+		}
+	})
+}
@@ -4,8 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"os"
-	"sync"
+	"strings"
 
 	"yaca/models"
 	"yaca/pkg/logger"
@@ -13,136 +12,225 @@ import (
 	"github.com/cloudflare/cloudflare-go/v4"
 	"github.com/cloudflare/cloudflare-go/v4/dns"
 	"github.com/cloudflare/cloudflare-go/v4/option"
+	"github.com/cloudflare/cloudflare-go/v4/packages/pagination"
 	"github.com/cloudflare/cloudflare-go/v4/zones"
 )
 
-var once sync.Once
-var client *cloudflare.Client
+// Cloudflare is the DNSProvider backed by the Cloudflare API
+type Cloudflare struct {
+	api  *cloudflare.Client
+	mode AuthMode
+}
 
-var GetSingletonClient = getSingletonClient
+// NewCloudflare returns a provider making its calls with the given API
+// client, which is expected to authenticate with an API token
+func NewCloudflare(api *cloudflare.Client) *Cloudflare {
+	return &Cloudflare{api: api, mode: AuthModeToken}
+}
 
-func getSingletonClient() *cloudflare.Client {
-	if client == nil {
-		once.Do(func() {
-			logger.Debug("Initializing singleton Cloudflare client")
+// NewCloudflareFromSource returns a provider authenticated with the
+// credentials read from the source, or an error wrapping ErrAuth if they
+// are missing or inconsistent. The credentials are redacted from the logs.
+func NewCloudflareFromSource(source CredentialSource) (*Cloudflare, error) {
+	logger.Debug("Initializing Cloudflare client")
 
-			apiEmail := os.Getenv("CLOUDFLARE_API_EMAIL")
-			apiToken := os.Getenv("CLOUDFLARE_API_TOKEN")
-			
-			// Log that we're using credentials without exposing them
-			logger.Debug("Creating Cloudflare client",
-				slog.Bool("has_email", apiEmail != ""),
-				slog.Bool("has_token", apiToken != ""))
+	credentials, err := source.Credentials()
+	if err != nil {
+		return nil, err
+	}
+	logger.RedactSecrets(credentials.Token, credentials.Key)
 
-			client = cloudflare.NewClient(
-				option.WithAPIEmail(apiEmail),
-				option.WithAPIToken(apiToken),
-			)
-		})
-	} else {
-		logger.Debug("Using existing Cloudflare client instance")
+	mode, authOptions, err := credentials.options()
+	if err != nil {
+		return nil, err
 	}
 
-	return client
+	// Log that we're using credentials without exposing them
+	logger.Debug("Creating Cloudflare client",
+		slog.String("auth_mode", string(mode)),
+		slog.Bool("has_email", credentials.Email != ""))
+
+	// Retries are handled by withRetry, which knows which calls are safe to
+	// repeat
+	api := cloudflare.NewClient(append(authOptions, option.WithMaxRetries(0))...)
+	return &Cloudflare{api: api, mode: mode}, nil
 }
 
-var GetZoneIDByName = getZoneIDByName
+// GetZoneIDByName returns the ID of the zone with the given name
+func (c *Cloudflare) GetZoneIDByName(ctx context.Context, zoneName string) (string, error) {
+	zone, err := c.findZone(ctx, zoneName)
+	if err != nil {
+		return "", err
+	}
+	return zone.ID, nil
+}
 
-func getZoneIDByName(zoneName string) (string, error) {
+// findZone returns the zone with the given name
+func (c *Cloudflare) findZone(ctx context.Context, zoneName string) (zones.Zone, error) {
 	logger.Debug("Retrieving zone ID",
 		slog.String("zone_name", zoneName))
 
-	client := GetSingletonClient()
-
-	page, err := client.Zones.List(context.TODO(), zones.ZoneListParams{
-		Name: cloudflare.F(zoneName),
+	page, err := withRetry(ctx, "list zones", isTransient, func(int) (*pagination.V4PagePaginationArray[zones.Zone], error) {
+		return c.api.Zones.List(ctx, zones.ZoneListParams{
+			Name: cloudflare.F(zoneName),
+		})
 	})
 	if err != nil {
 		logger.Error("Failed to list zones",
 			slog.String("zone_name", zoneName),
 			slog.String("error", err.Error()))
-		return "", fmt.Errorf("failed to list zones: %w", err)
+		return zones.Zone{}, fmt.Errorf("failed to list zones: %w", classifyError(err))
 	}
 
 	if len(page.Result) == 0 {
 		logger.Warn("No zone found",
 			slog.String("zone_name", zoneName))
-		return "", fmt.Errorf("no zone found with name: %s", zoneName)
+		return zones.Zone{}, fmt.Errorf("%w: no zone found with name: %s", ErrZoneNotFound, zoneName)
 	}
 
-	zoneID := page.Result[0].ID
+	zone := page.Result[0]
 	logger.Debug("Zone ID retrieved",
-		slog.String("zone_id", zoneID), // Will be masked
+		slog.String("zone_id", zone.ID), // Will be masked
 		slog.String("zone_name", zoneName))
 
-	return zoneID, nil
+	return zone, nil
 }
 
-var DoesRecordExistOnZone = doesRecordExistOnZone
-
-func doesRecordExistOnZone(zoneID, recordName string) (string, error) {
+// GetRecords returns every record matching the name and type of the given
+// record. For multi-value types the content must match as well, so that e.g.
+// several TXT records on one name are told apart. An empty type matches
+// records of any type.
+func (c *Cloudflare) GetRecords(ctx context.Context, zoneID string, record models.Record) ([]models.RecordData, error) {
 	logger.Debug("Checking record existence",
 		slog.String("zone_id", zoneID), // Will be masked
-		slog.String("record_name", recordName))
-
-	client := GetSingletonClient()
+		slog.String("record_name", record.Record),
+		slog.String("record_type", record.Type))
 
-	page, err := client.DNS.Records.List(context.TODO(), dns.RecordListParams{
-		ZoneID: cloudflare.F(zoneID),
-	})
+	records, err := c.ListRecords(ctx, zoneID, record.Record, record.Type)
 	if err != nil {
-		logger.Error("Failed to list DNS records",
-			slog.String("zone_id", zoneID),
-			slog.String("error", err.Error()))
-		return "", fmt.Errorf("failed to list DNS records: %w", err)
+		return nil, err
 	}
 
-	for _, record := range page.Result {
-		if record.Name == recordName {
-			logger.Debug("Record found",
-				slog.String("record_id", record.ID), // Will be masked
-				slog.String("record_name", recordName))
-			return record.ID, nil
-		}
+	records = filterByContent(records, record)
+	if len(records) > 0 {
+		logger.Debug("Record found",
+			slog.String("record_id", records[0].RecordID), // Will be masked
+			slog.String("record_name", record.Record),
+			slog.Int("matches", len(records)))
+		return records, nil
 	}
 
 	logger.Debug("Record not found",
-		slog.String("record_name", recordName))
-	return "", nil
+		slog.String("record_name", record.Record))
+	return nil, nil
 }
 
-var CreateRecordOnZone = createRecordOnZone
+// recordsPerPage is the page size used when listing DNS records
+const recordsPerPage = 100
+
+// ListRecords returns every record with the given name and type, walking all
+// result pages. Filtering is done server-side; an empty recordType matches
+// records of any type.
+func (c *Cloudflare) ListRecords(ctx context.Context, zoneID, recordName, recordType string) ([]models.RecordData, error) {
+	logger.Debug("Listing DNS records",
+		slog.String("zone_id", zoneID), // Will be masked
+		slog.String("record_name", recordName),
+		slog.String("record_type", recordType))
+
+	params := dns.RecordListParams{
+		ZoneID:  cloudflare.F(zoneID),
+		Name:    cloudflare.F(dns.RecordListParamsName{Exact: cloudflare.F(recordName)}),
+		PerPage: cloudflare.F(float64(recordsPerPage)),
+	}
+	if recordType != "" {
+		params.Type = cloudflare.F(dns.RecordListParamsType(recordType))
+	}
 
-func createRecordOnZone(zoneID string, record models.Record) (bool, error) {
-	return handleRecord(context.TODO(), models.RecordData{
+	var records []models.RecordData
+	for pageNumber := 1; ; pageNumber++ {
+		params.Page = cloudflare.F(float64(pageNumber))
+		page, err := withRetry(ctx, "list DNS records", isTransient, func(int) (*pagination.V4PagePaginationArray[dns.RecordResponse], error) {
+			return c.api.DNS.Records.List(ctx, params)
+		})
+		if err != nil {
+			logger.Error("Failed to list DNS records",
+				slog.String("zone_id", zoneID),
+				slog.String("error", err.Error()))
+			return nil, fmt.Errorf("failed to list DNS records: %w", classifyError(err))
+		}
+
+		for _, record := range page.Result {
+			if !strings.EqualFold(record.Name, recordName) || (recordType != "" && string(record.Type) != recordType) {
+				continue
+			}
+			records = append(records, models.RecordData{
+				ZoneID:   zoneID,
+				RecordID: record.ID,
+				Record:   recordFromResponse(record),
+			})
+		}
+
+		if len(page.Result) < recordsPerPage {
+			break
+		}
+	}
+
+	logger.Debug("DNS records listed",
+		slog.String("record_name", recordName),
+		slog.Int("count", len(records)))
+	return records, nil
+}
+
+// recordFromResponse converts a Cloudflare DNS record into a models.Record
+func recordFromResponse(response dns.RecordResponse) models.Record {
+	record := models.Record{
+		Record: response.Name,
+		Proxy:  response.Proxied,
+		Target: response.Content,
+		Ttl:    float64(response.TTL),
+		Type:   string(response.Type),
+	}
+
+	switch record.Type {
+	case "MX", "SRV":
+		priority := int(response.Priority)
+		record.Priority = &priority
+	}
+
+	return record
+}
+
+// CreateRecord creates the record and returns the ID assigned to it
+func (c *Cloudflare) CreateRecord(ctx context.Context, zoneID string, record models.Record) (string, error) {
+	return c.handleRecord(ctx, models.RecordData{
 		ZoneID: zoneID,
 		Record: record,
 	}, "Creating")
 }
 
-var UpdateRecordOnZone = updateRecordOnZone
-
-func updateRecordOnZone(zoneID, recordID string, record models.Record) (bool, error) {
-	return handleRecord(context.TODO(), models.RecordData{
+// UpdateRecord replaces the record with the given ID
+func (c *Cloudflare) UpdateRecord(ctx context.Context, zoneID, recordID string, record models.Record) (bool, error) {
+	_, err := c.handleRecord(ctx, models.RecordData{
 		ZoneID:   zoneID,
 		RecordID: recordID,
 		Record:   record,
 	}, "Updating")
+	return err == nil, err
 }
 
-var DeleteRecordOnZone = deleteRecordOnZone
-
-func deleteRecordOnZone(zoneID, recordID string, record models.Record) (bool, error) {
-	return handleRecord(context.TODO(), models.RecordData{
+// DeleteRecord deletes the record with the given ID
+func (c *Cloudflare) DeleteRecord(ctx context.Context, zoneID, recordID string, record models.Record) (bool, error) {
+	_, err := c.handleRecord(ctx, models.RecordData{
 		ZoneID:   zoneID,
 		RecordID: recordID,
 		Record:   record,
 	}, "Deleting")
+	return err == nil, err
 }
 
-var handleRecord = handleRecordImpl
-
-func handleRecordImpl(ctx context.Context, recordData models.RecordData, operation string) (bool, error) {
+// handleRecord runs the operation on the record and returns the ID of the
+// affected record
+func (c *Cloudflare) handleRecord(ctx context.Context, recordData models.RecordData, operation string) (string, error) {
 	// Log operation with appropriate details
 	logger.Info("DNS operation started",
 		slog.String("operation", operation),
@@ -159,60 +247,142 @@ func handleRecordImpl(ctx context.Context, recordData models.RecordData, operati
 			slog.String("operation", operation))
 	}
 
-	client := GetSingletonClient()
+	recordID := recordData.RecordID
 	var err error
 
 	switch operation {
 	case "Creating":
 		body := dns.RecordNewParamsBody{
 			Name:    cloudflare.F(recordData.Record.Record),
-			Content: cloudflare.F(recordData.Record.Target),
 			Proxied: cloudflare.F(recordData.Record.Proxy),
 			TTL:     cloudflare.F(dns.TTL(recordData.Record.Ttl)),
 		}
+		data, dataErr := recordDataParam(recordData.Record)
+		if dataErr != nil {
+			logger.Error("Invalid record data",
+				slog.String("type", recordData.Record.Type),
+				slog.String("error", dataErr.Error()))
+			return "", fmt.Errorf("invalid %s record data: %w", recordData.Record.Type, dataErr)
+		}
+		if data != nil {
+			body.Data = cloudflare.F(data)
+		} else {
+			body.Content = cloudflare.F(recordData.Record.Content())
+		}
+		if recordData.Record.Priority != nil {
+			body.Priority = cloudflare.F(float64(*recordData.Record.Priority))
+		}
 		switch recordData.Record.Type {
 		case "A":
 			body.Type = cloudflare.F(dns.RecordNewParamsBodyTypeA)
+		case "AAAA":
+			body.Type = cloudflare.F(dns.RecordNewParamsBodyTypeAAAA)
+		case "CAA":
+			body.Type = cloudflare.F(dns.RecordNewParamsBodyTypeCAA)
 		case "CNAME":
 			body.Type = cloudflare.F(dns.RecordNewParamsBodyTypeCNAME)
+		case "HTTPS":
+			body.Type = cloudflare.F(dns.RecordNewParamsBodyTypeHTTPS)
+		case "MX":
+			body.Type = cloudflare.F(dns.RecordNewParamsBodyTypeMX)
+		case "NS":
+			body.Type = cloudflare.F(dns.RecordNewParamsBodyTypeNS)
+		case "PTR":
+			body.Type = cloudflare.F(dns.RecordNewParamsBodyTypePTR)
+		case "SRV":
+			body.Type = cloudflare.F(dns.RecordNewParamsBodyTypeSRV)
+		case "SVCB":
+			body.Type = cloudflare.F(dns.RecordNewParamsBodyTypeSVCB)
+		case "TXT":
+			body.Type = cloudflare.F(dns.RecordNewParamsBodyTypeTXT)
 		default:
 			logger.Error("Unsupported record type",
 				slog.String("type", recordData.Record.Type))
-			return false, fmt.Errorf("unsupported record type: %s", recordData.Record.Type)
+			return "", fmt.Errorf("unsupported record type: %s", recordData.Record.Type)
 		}
-		_, err = client.DNS.Records.New(ctx, dns.RecordNewParams{
-			ZoneID: cloudflare.F(recordData.ZoneID),
-			Body:   body,
+		// Creating is not idempotent, so it is only retried when the API
+		// rejected the request because of rate limiting
+		var response *dns.RecordResponse
+		response, err = withRetry(ctx, "create DNS record", isRateLimited, func(int) (*dns.RecordResponse, error) {
+			return c.api.DNS.Records.New(ctx, dns.RecordNewParams{
+				ZoneID: cloudflare.F(recordData.ZoneID),
+				Body:   body,
+			})
 		})
+		if err == nil {
+			recordID = response.ID
+		}
 	case "Updating":
 		body := dns.RecordEditParamsBody{
 			Name:    cloudflare.F(recordData.Record.Record),
-			Content: cloudflare.F(recordData.Record.Target),
 			Proxied: cloudflare.F(recordData.Record.Proxy),
 			TTL:     cloudflare.F(dns.TTL(recordData.Record.Ttl)),
 		}
+		data, dataErr := recordDataParam(recordData.Record)
+		if dataErr != nil {
+			logger.Error("Invalid record data",
+				slog.String("type", recordData.Record.Type),
+				slog.String("error", dataErr.Error()))
+			return "", fmt.Errorf("invalid %s record data: %w", recordData.Record.Type, dataErr)
+		}
+		if data != nil {
+			body.Data = cloudflare.F(data)
+		} else {
+			body.Content = cloudflare.F(recordData.Record.Content())
+		}
+		if recordData.Record.Priority != nil {
+			body.Priority = cloudflare.F(float64(*recordData.Record.Priority))
+		}
 		switch recordData.Record.Type {
 		case "A":
 			body.Type = cloudflare.F(dns.RecordEditParamsBodyTypeA)
+		case "AAAA":
+			body.Type = cloudflare.F(dns.RecordEditParamsBodyTypeAAAA)
+		case "CAA":
+			body.Type = cloudflare.F(dns.RecordEditParamsBodyTypeCAA)
 		case "CNAME":
 			body.Type = cloudflare.F(dns.RecordEditParamsBodyTypeCNAME)
+		case "HTTPS":
+			body.Type = cloudflare.F(dns.RecordEditParamsBodyTypeHTTPS)
+		case "MX":
+			body.Type = cloudflare.F(dns.RecordEditParamsBodyTypeMX)
+		case "NS":
+			body.Type = cloudflare.F(dns.RecordEditParamsBodyTypeNS)
+		case "PTR":
+			body.Type = cloudflare.F(dns.RecordEditParamsBodyTypePTR)
+		case "SRV":
+			body.Type = cloudflare.F(dns.RecordEditParamsBodyTypeSRV)
+		case "SVCB":
+			body.Type = cloudflare.F(dns.RecordEditParamsBodyTypeSVCB)
+		case "TXT":
+			body.Type = cloudflare.F(dns.RecordEditParamsBodyTypeTXT)
 		default:
 			logger.Error("Unsupported record type",
 				slog.String("type", recordData.Record.Type))
-			return false, fmt.Errorf("unsupported record type: %s", recordData.Record.Type)
+			return "", fmt.Errorf("unsupported record type: %s", recordData.Record.Type)
 		}
-		_, err = client.DNS.Records.Edit(ctx, recordData.RecordID, dns.RecordEditParams{
-			ZoneID: cloudflare.F(recordData.ZoneID),
-			Body:   body,
+		_, err = withRetry(ctx, "update DNS record", isTransient, func(int) (*dns.RecordResponse, error) {
+			return c.api.DNS.Records.Edit(ctx, recordData.RecordID, dns.RecordEditParams{
+				ZoneID: cloudflare.F(recordData.ZoneID),
+				Body:   body,
+			})
 		})
 	case "Deleting":
-		_, err = client.DNS.Records.Delete(ctx, recordData.RecordID, dns.RecordDeleteParams{
-			ZoneID: cloudflare.F(recordData.ZoneID),
+		_, err = withRetry(ctx, "delete DNS record", isTransient, func(attempt int) (*dns.RecordDeleteResponse, error) {
+			response, err := c.api.DNS.Records.Delete(ctx, recordData.RecordID, dns.RecordDeleteParams{
+				ZoneID: cloudflare.F(recordData.ZoneID),
+			})
+			// A retried delete may find the record already removed by an
+			// earlier attempt whose response was lost
+			if attempt > 1 && isNotFound(err) {
+				return response, nil
+			}
+			return response, err
 		})
 	default:
 		logger.Error("Unsupported operation",
 			slog.String("operation", operation))
-		return false, fmt.Errorf("unsupported operation: %s", operation)
+		return "", fmt.Errorf("unsupported operation: %s", operation)
 	}
 
 	if err != nil {
@@ -220,11 +390,11 @@ func handleRecordImpl(ctx context.Context, recordData models.RecordData, operati
 			slog.String("operation", operation),
 			slog.String("record_name", recordData.Record.Record),
 			slog.String("error", err.Error()))
-		return false, fmt.Errorf("failed to %s DNS record: %w", map[string]string{
+		return "", fmt.Errorf("failed to %s DNS record: %w", map[string]string{
 			"Creating": "create",
 			"Updating": "update",
 			"Deleting": "delete",
-		}[operation], err)
+		}[operation], classifyError(err))
 	}
 
 	logger.Info("DNS operation completed successfully",
@@ -232,5 +402,56 @@ func handleRecordImpl(ctx context.Context, recordData models.RecordData, operati
 		slog.String("record_name", recordData.Record.Record),
 		slog.String("record_type", recordData.Record.Type))
 
-	return true, nil
+	return recordID, nil
+}
+
+// recordDataParam returns the structured data object for record types that
+// Cloudflare expects as "data" instead of "content", or nil otherwise
+func recordDataParam(record models.Record) (interface{}, error) {
+	switch record.Type {
+	case "CAA":
+		if record.CAA == nil {
+			return nil, nil
+		}
+		return dns.CAARecordDataParam{
+			Flags: cloudflare.F(float64(record.CAA.Flags)),
+			Tag:   cloudflare.F(record.CAA.Tag),
+			Value: cloudflare.F(record.CAA.Value),
+		}, nil
+	case "HTTPS":
+		svcb, err := models.ParseSVCB(record.Target)
+		if err != nil {
+			return nil, err
+		}
+		return dns.HTTPSRecordDataParam{
+			Priority: cloudflare.F(float64(svcb.Priority)),
+			Target:   cloudflare.F(svcb.Target),
+			Value:    cloudflare.F(svcb.Value),
+		}, nil
+	case "SVCB":
+		svcb, err := models.ParseSVCB(record.Target)
+		if err != nil {
+			return nil, err
+		}
+		return dns.SVCBRecordDataParam{
+			Priority: cloudflare.F(float64(svcb.Priority)),
+			Target:   cloudflare.F(svcb.Target),
+			Value:    cloudflare.F(svcb.Value),
+		}, nil
+	case "SRV":
+		if record.SRV == nil {
+			return nil, nil
+		}
+		data := dns.SRVRecordDataParam{
+			Port:   cloudflare.F(float64(record.SRV.Port)),
+			Target: cloudflare.F(record.Target),
+			Weight: cloudflare.F(float64(record.SRV.Weight)),
+		}
+		if record.Priority != nil {
+			data.Priority = cloudflare.F(float64(*record.Priority))
+		}
+		return data, nil
+	default:
+		return nil, nil
+	}
 }
@@ -1,12 +1,14 @@
 package client
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"yaca/models"
+	"yaca/pkg/cloudflaretest"
 
 	"github.com/cloudflare/cloudflare-go/v4"
 	"github.com/cloudflare/cloudflare-go/v4/option"
@@ -24,144 +26,315 @@ func setupMockServer(t *testing.T, handler http.Handler) (*httptest.Server, *clo
 			},
 		}),
 		option.WithBaseURL(server.URL),
+		option.WithMaxRetries(0),
 	)
 	return server, client
 }
 
-func TestGetZoneIDByName(t *testing.T) {
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		fmt.Fprintln(w, `{
-			"result": [
-				{
-					"id": "test-zone-id",
-					"name": "example.com"
-				}
-			],
-			"success": true,
-			"errors": [],
-			"messages": []
-		}`)
-	})
-
-	server, cfClient := setupMockServer(t, handler)
-	defer server.Close()
+// setupFakeServer starts a fake Cloudflare API with a single zone and returns
+// a provider talking to it
+func setupFakeServer(t *testing.T) (*cloudflaretest.Server, string, *Cloudflare) {
+	server := cloudflaretest.NewServer()
+	t.Cleanup(server.Close)
+	zoneID := server.AddZone("example.com")
+	return server, zoneID, NewCloudflare(server.Client())
+}
 
-	// Replace the singleton client with the mock client
-	client = cfClient
+func TestGetZoneIDByName(t *testing.T) {
+	server, zoneID, provider := setupFakeServer(t)
+	server.AddZone("example.org")
 
-	zoneID, err := GetZoneIDByName("example.com")
+	got, err := provider.GetZoneIDByName(context.Background(), "example.com")
 	if err != nil {
 		t.Errorf("GetZoneIDByName() returned an error: %v", err)
 	}
 
-	if zoneID != "test-zone-id" {
-		t.Errorf("GetZoneIDByName() returned incorrect zone ID, got: %s, want: %s", zoneID, "test-zone-id")
+	if got != zoneID {
+		t.Errorf("GetZoneIDByName() returned incorrect zone ID, got: %s, want: %s", got, zoneID)
 	}
 }
 
-func TestDoesRecordExistOnZone(t *testing.T) {
+func TestGetRecords(t *testing.T) {
 	t.Run("should return record ID when record exists", func(t *testing.T) {
-		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Content-Type", "application/json")
-			fmt.Fprintln(w, `{
-				"result": [
-					{
-						"id": "test-record-id",
-						"name": "test.example.com"
-					}
-				],
-				"success": true,
-				"errors": [],
-				"messages": []
-			}`)
-		})
-
-		server, cfClient := setupMockServer(t, handler)
-		defer server.Close()
-
-		client = cfClient
-
-		recordID, err := DoesRecordExistOnZone("test-zone-id", "test.example.com")
+		server, zoneID, provider := setupFakeServer(t)
+		recordID := server.AddRecord(zoneID, cloudflaretest.Record{Name: "test.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600})
+		server.AddRecord(zoneID, cloudflaretest.Record{Name: "test.example.com", Type: "AAAA", Content: "2001:db8::1", TTL: 3600})
+
+		records, err := provider.GetRecords(context.Background(), zoneID, models.Record{Record: "test.example.com", Type: "A"})
 		if err != nil {
-			t.Errorf("DoesRecordExistOnZone() returned an error: %v", err)
+			t.Errorf("GetRecords() returned an error: %v", err)
 		}
 
-		if recordID != "test-record-id" {
-			t.Errorf("DoesRecordExistOnZone() returned incorrect record ID, got: %s, want: %s", recordID, "test-record-id")
+		if len(records) != 1 || records[0].RecordID != recordID {
+			t.Errorf("GetRecords() returned incorrect records, got: %+v, want ID: %s", records, recordID)
 		}
 	})
 
-	t.Run("should return empty string when record does not exist", func(t *testing.T) {
-		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Content-Type", "application/json")
-			fmt.Fprintln(w, `{
-				"result": [],
-				"success": true,
-				"errors": [],
-				"messages": []
-			}`)
-		})
+	t.Run("should match multi-value records on content", func(t *testing.T) {
+		server, zoneID, provider := setupFakeServer(t)
+		server.AddRecord(zoneID, cloudflaretest.Record{Name: "example.com", Type: "TXT", Content: `"v=spf1 -all"`, TTL: 3600})
+		recordID := server.AddRecord(zoneID, cloudflaretest.Record{Name: "example.com", Type: "TXT", Content: `"token=abc"`, TTL: 3600})
 
-		server, cfClient := setupMockServer(t, handler)
-		defer server.Close()
+		records, err := provider.GetRecords(context.Background(), zoneID, models.Record{Record: "example.com", Type: "TXT", Target: "token=abc"})
+		if err != nil {
+			t.Errorf("GetRecords() returned an error: %v", err)
+		}
+
+		if len(records) != 1 || records[0].RecordID != recordID {
+			t.Errorf("GetRecords() returned incorrect records, got: %+v, want ID: %s", records, recordID)
+		}
+	})
 
-		client = cfClient
+	t.Run("should return nothing when record does not exist", func(t *testing.T) {
+		_, zoneID, provider := setupFakeServer(t)
 
-		recordID, err := DoesRecordExistOnZone("test-zone-id", "test.example.com")
+		records, err := provider.GetRecords(context.Background(), zoneID, models.Record{Record: "test.example.com", Type: "A"})
 		if err != nil {
-			t.Errorf("DoesRecordExistOnZone() returned an error: %v", err)
+			t.Errorf("GetRecords() returned an error: %v", err)
 		}
 
-		if recordID != "" {
-			t.Errorf("DoesRecordExistOnZone() returned incorrect record ID, got: %s, want: %s", recordID, "")
+		if len(records) != 0 {
+			t.Errorf("GetRecords() returned incorrect records, got: %+v, want none", records)
 		}
 	})
 }
 
 func TestHandleRecord(t *testing.T) {
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		fmt.Fprintln(w, `{
-			"result": {},
-			"success": true,
-			"errors": [],
-			"messages": []
-		}`)
-	})
-
-	server, cfClient := setupMockServer(t, handler)
-	defer server.Close()
-
-	client = cfClient
+	server, zoneID, provider := setupFakeServer(t)
+	ctx := context.Background()
 
 	record := models.Record{
 		Record: "test.example.com",
 		Type:   "A",
-		Target: "127.0.0.1",
+		Target: "192.0.2.1",
 		Proxy:  true,
 		Ttl:    3600,
 	}
 
+	var recordID string
 	t.Run("should create record", func(t *testing.T) {
-		_, err := CreateRecordOnZone("test-zone-id", record)
+		var err error
+		recordID, err = provider.CreateRecord(ctx, zoneID, record)
 		if err != nil {
-			t.Errorf("CreateRecordOnZone() returned an error: %v", err)
+			t.Fatalf("CreateRecord() returned an error: %v", err)
+		}
+		records := server.Records(zoneID)
+		if len(records) != 1 || records[0].ID != recordID || records[0].Content != "192.0.2.1" || !records[0].Proxied {
+			t.Errorf("Unexpected zone contents: %+v", records)
 		}
 	})
 
 	t.Run("should update record", func(t *testing.T) {
-		_, err := UpdateRecordOnZone("test-zone-id", "test-record-id", record)
+		updated := record
+		updated.Target = "192.0.2.2"
+		if _, err := provider.UpdateRecord(ctx, zoneID, recordID, updated); err != nil {
+			t.Fatalf("UpdateRecord() returned an error: %v", err)
+		}
+		if records := server.Records(zoneID); len(records) != 1 || records[0].Content != "192.0.2.2" {
+			t.Errorf("Unexpected zone contents: %+v", records)
+		}
+	})
+
+	t.Run("should create and update AAAA record", func(t *testing.T) {
+		aaaaRecord := record
+		aaaaRecord.Type = "AAAA"
+		aaaaRecord.Target = "2001:db8::1"
+		aaaaID, err := provider.CreateRecord(ctx, zoneID, aaaaRecord)
 		if err != nil {
-			t.Errorf("UpdateRecordOnZone() returned an error: %v", err)
+			t.Fatalf("CreateRecord() returned an error: %v", err)
+		}
+
+		aaaaRecord.Target = "2001:db8::2"
+		if _, err := provider.UpdateRecord(ctx, zoneID, aaaaID, aaaaRecord); err != nil {
+			t.Errorf("UpdateRecord() returned an error: %v", err)
+		}
+		if records := server.Records(zoneID); len(records) != 2 || records[1].Content != "2001:db8::2" {
+			t.Errorf("Unexpected zone contents: %+v", records)
 		}
 	})
 
 	t.Run("should delete record", func(t *testing.T) {
-		_, err := DeleteRecordOnZone("test-zone-id", "test-record-id", record)
-		if err != nil {
-			t.Errorf("DeleteRecordOnZone() returned an error: %v", err)
+		if _, err := provider.DeleteRecord(ctx, zoneID, recordID, record); err != nil {
+			t.Fatalf("DeleteRecord() returned an error: %v", err)
+		}
+		if records := server.Records(zoneID); len(records) != 1 || records[0].Type != "AAAA" {
+			t.Errorf("Unexpected zone contents: %+v", records)
+		}
+	})
+}
+
+func TestHandleRecordTXT(t *testing.T) {
+	server, zoneID, provider := setupFakeServer(t)
+
+	record := models.Record{
+		Record: "test.example.com",
+		Type:   "TXT",
+		Target: `verification "token"`,
+		Ttl:    3600,
+	}
+
+	if _, err := provider.CreateRecord(context.Background(), zoneID, record); err != nil {
+		t.Fatalf("CreateRecord() returned an error: %v", err)
+	}
+
+	stored := server.Records(zoneID)[0]
+	if stored.Type != "TXT" {
+		t.Errorf("Record type is incorrect, got: %v, want: %s", stored.Type, "TXT")
+	}
+	want := `"verification \"token\""`
+	if stored.Content != want {
+		t.Errorf("Record content is incorrect, got: %v, want: %s", stored.Content, want)
+	}
+}
+
+func TestHandleRecordMX(t *testing.T) {
+	server, zoneID, provider := setupFakeServer(t)
+	recordID := server.AddRecord(zoneID, cloudflaretest.Record{Name: "example.com", Type: "MX", Content: "mail.example.com", TTL: 3600})
+
+	priority := 10
+	record := models.Record{
+		Record:   "example.com",
+		Type:     "MX",
+		Target:   "mail.example.com",
+		Priority: &priority,
+		Ttl:      3600,
+	}
+
+	if _, err := provider.UpdateRecord(context.Background(), zoneID, recordID, record); err != nil {
+		t.Fatalf("UpdateRecord() returned an error: %v", err)
+	}
+
+	stored := server.Records(zoneID)[0]
+	if stored.Priority == nil || *stored.Priority != 10 {
+		t.Errorf("Record priority is incorrect, got: %v, want: %d", stored.Priority, 10)
+	}
+}
+
+func TestHandleRecordSRV(t *testing.T) {
+	server, zoneID, provider := setupFakeServer(t)
+
+	priority := 10
+	record := models.Record{
+		Record:   "_sip._tcp.example.com",
+		Type:     "SRV",
+		Target:   "sip.example.com",
+		Priority: &priority,
+		SRV:      &models.SRVData{Service: "sip", Proto: "tcp", Weight: 5, Port: 5060},
+		Ttl:      3600,
+	}
+
+	if _, err := provider.CreateRecord(context.Background(), zoneID, record); err != nil {
+		t.Fatalf("CreateRecord() returned an error: %v", err)
+	}
+
+	expected := map[string]any{
+		"priority": float64(10),
+		"weight":   float64(5),
+		"port":     float64(5060),
+		"target":   "sip.example.com",
+	}
+	data := server.Records(zoneID)[0].Data
+	for key, want := range expected {
+		if data[key] != want {
+			t.Errorf("Record data %s is incorrect, got: %v, want: %v", key, data[key], want)
+		}
+	}
+}
+
+func TestHandleRecordCAA(t *testing.T) {
+	server, zoneID, provider := setupFakeServer(t)
+	recordID := server.AddRecord(zoneID, cloudflaretest.Record{Name: "example.com", Type: "CAA", Content: `0 issue "ca.example.net"`, TTL: 3600})
+
+	record := models.Record{
+		Record: "example.com",
+		Type:   "CAA",
+		Target: "letsencrypt.org",
+		CAA:    &models.CAAData{Flags: 0, Tag: "issue", Value: "letsencrypt.org"},
+		Ttl:    3600,
+	}
+
+	if _, err := provider.UpdateRecord(context.Background(), zoneID, recordID, record); err != nil {
+		t.Fatalf("UpdateRecord() returned an error: %v", err)
+	}
+
+	expected := map[string]any{
+		"flags": float64(0),
+		"tag":   "issue",
+		"value": "letsencrypt.org",
+	}
+	data := server.Records(zoneID)[0].Data
+	for key, want := range expected {
+		if data[key] != want {
+			t.Errorf("Record data %s is incorrect, got: %v, want: %v", key, data[key], want)
+		}
+	}
+}
+
+func TestHandleRecordHTTPS(t *testing.T) {
+	t.Run("should send structured data", func(t *testing.T) {
+		server, zoneID, provider := setupFakeServer(t)
+		record := models.Record{
+			Record: "example.com",
+			Type:   "HTTPS",
+			Target: `1 . alpn="h3,h2" ipv4hint=192.0.2.1`,
+			Ttl:    3600,
+		}
+
+		if _, err := provider.CreateRecord(context.Background(), zoneID, record); err != nil {
+			t.Fatalf("CreateRecord() returned an error: %v", err)
+		}
+
+		expected := map[string]any{
+			"priority": float64(1),
+			"target":   ".",
+			"value":    `alpn="h3,h2" ipv4hint="192.0.2.1"`,
+		}
+		data := server.Records(zoneID)[0].Data
+		for key, want := range expected {
+			if data[key] != want {
+				t.Errorf("Record data %s is incorrect, got: %v, want: %v", key, data[key], want)
+			}
+		}
+	})
+
+	t.Run("should reject malformed SvcParams before calling the API", func(t *testing.T) {
+		server, zoneID, provider := setupFakeServer(t)
+		record := models.Record{
+			Record: "example.com",
+			Type:   "SVCB",
+			Target: "1 . ipv4hint=not-an-ip",
+			Ttl:    3600,
+		}
+
+		_, err := provider.CreateRecord(context.Background(), zoneID, record)
+		if err == nil {
+			t.Error("CreateRecord() should have returned an error")
+		}
+		if requests := server.Requests(); len(requests) != 0 {
+			t.Errorf("Expected no API requests, got %d", len(requests))
 		}
 	})
 }
 
+func TestListRecords(t *testing.T) {
+	server, zoneID, provider := setupFakeServer(t)
+	for i := 0; i <= recordsPerPage; i++ {
+		server.AddRecord(zoneID, cloudflaretest.Record{Name: "dev.example.com", Type: "NS", Content: fmt.Sprintf("ns%d.example.net", i), TTL: 3600})
+	}
+	server.AddRecord(zoneID, cloudflaretest.Record{Name: "dev.example.com", Type: "A", Content: "192.0.2.1", TTL: 3600})
+
+	records, err := provider.ListRecords(context.Background(), zoneID, "dev.example.com", "NS")
+	if err != nil {
+		t.Fatalf("ListRecords() returned an error: %v", err)
+	}
+
+	if pages := len(server.Requests()); pages != 2 {
+		t.Errorf("Expected 2 pages to be requested, got %d", pages)
+	}
+	if len(records) != recordsPerPage+1 {
+		t.Errorf("Expected %d records, got %d", recordsPerPage+1, len(records))
+	}
+	if records[0].Record.Target != "ns0.example.net" {
+		t.Errorf("First record is incorrect, got: %+v", records[0])
+	}
+}
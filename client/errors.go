@@ -0,0 +1,111 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"path"
+
+	"github.com/cloudflare/cloudflare-go/v4"
+)
+
+// Classes of errors returned by the client, matched with errors.Is
+var (
+	ErrAuth           error = &errorClass{"auth", "authentication failed"}
+	ErrConflict       error = &errorClass{"conflict", "record conflicts with an existing record"}
+	ErrRateLimited    error = &errorClass{"rate_limited", "rate limited by the Cloudflare API"}
+	ErrRecordNotFound error = &errorClass{"record_not_found", "record not found"}
+	ErrServer         error = &errorClass{"server_error", "Cloudflare API server error"}
+	ErrValidation     error = &errorClass{"validation", "request rejected by the Cloudflare API"}
+	ErrZoneNotFound   error = &errorClass{"zone_not_found", "zone not found"}
+)
+
+// errorClass is a class of errors, named by the error_code step output it
+// is reported with, so that callers can map it without importing this package
+type errorClass struct {
+	code    string
+	message string
+}
+
+func (e *errorClass) Error() string {
+	return e.message
+}
+
+// ErrorCode returns the name of the class
+func (e *errorClass) ErrorCode() string {
+	return e.code
+}
+
+// recordNotFoundCode is the Cloudflare error code reported when a record
+// does not exist
+const recordNotFoundCode = 81044
+
+// conflictCodes lists the Cloudflare error codes reported when a record
+// clashes with one that already exists
+var conflictCodes = map[int64]bool{
+	81053: true, // an A, AAAA or CNAME record already exists with that host
+	81054: true, // a CNAME record already exists with that host
+	81057: true, // the record already exists
+	81058: true, // an identical record already exists
+}
+
+// APIError is a failed Cloudflare API call together with its class
+type APIError struct {
+	Class      error
+	StatusCode int
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *APIError) Unwrap() []error {
+	return []error{e.Class, e.Err}
+}
+
+// classifyError wraps an error returned by the SDK in an APIError when the
+// API responded, so that callers can tell failures apart with errors.Is
+func classifyError(err error) error {
+	var apiErr *cloudflare.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	class := ErrValidation
+	switch {
+	case apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden:
+		class = ErrAuth
+	case apiErr.StatusCode == http.StatusTooManyRequests:
+		class = ErrRateLimited
+	case apiErr.StatusCode == http.StatusConflict:
+		class = ErrConflict
+	case apiErr.StatusCode == http.StatusNotFound && isRecordNotFound(apiErr):
+		class = ErrRecordNotFound
+	case apiErr.StatusCode >= http.StatusInternalServerError:
+		class = ErrServer
+	default:
+		for _, data := range apiErr.Errors {
+			if conflictCodes[data.Code] {
+				class = ErrConflict
+				break
+			}
+		}
+	}
+
+	return &APIError{Class: class, StatusCode: apiErr.StatusCode, Err: err}
+}
+
+// isRecordNotFound reports whether a 404 response was for a single DNS
+// record, such as one deleted since it was listed
+func isRecordNotFound(apiErr *cloudflare.Error) bool {
+	for _, data := range apiErr.Errors {
+		if data.Code == recordNotFoundCode {
+			return true
+		}
+	}
+	if apiErr.Request == nil {
+		return false
+	}
+	matched, _ := path.Match("*/zones/*/dns_records/*", apiErr.Request.URL.Path)
+	return matched
+}
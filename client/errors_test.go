@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"yaca/models"
+	"yaca/pkg/cloudflaretest"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		code     int
+		expected error
+	}{
+		{"should classify unauthorized as auth", http.StatusUnauthorized, 10000, ErrAuth},
+		{"should classify forbidden as auth", http.StatusForbidden, 9109, ErrAuth},
+		{"should classify too many requests as rate limited", http.StatusTooManyRequests, 971, ErrRateLimited},
+		{"should classify server errors", http.StatusBadGateway, 10000, ErrServer},
+		{"should classify duplicate records as conflict", http.StatusBadRequest, 81057, ErrConflict},
+		{"should classify other client errors as validation", http.StatusBadRequest, 9005, ErrValidation},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recordSleeps(t)
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(test.status)
+				fmt.Fprintf(w, `{"result": null, "success": false, "errors": [{"code": %d, "message": "test error"}], "messages": []}`, test.code)
+			})
+
+			server, cfClient := setupMockServer(t, handler)
+			defer server.Close()
+
+			provider := NewCloudflare(cfClient)
+
+			_, err := provider.CreateRecord(context.Background(), "test-zone-id", models.Record{Record: "www.example.com", Type: "A", Target: "192.0.2.1", Ttl: 3600})
+
+			if !errors.Is(err, test.expected) {
+				t.Errorf("Expected error to be %v, got: %v", test.expected, err)
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != test.status {
+				t.Errorf("Expected an APIError with status %d, got: %v", test.status, err)
+			}
+		})
+	}
+
+	t.Run("should report a missing zone", func(t *testing.T) {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintln(w, `{"result": [], "success": true, "errors": [], "messages": []}`)
+		})
+
+		server, cfClient := setupMockServer(t, handler)
+		defer server.Close()
+
+		provider := NewCloudflare(cfClient)
+
+		_, err := provider.GetZoneIDByName(context.Background(), "example.com")
+
+		if !errors.Is(err, ErrZoneNotFound) {
+			t.Errorf("Expected ErrZoneNotFound, got: %v", err)
+		}
+	})
+
+	t.Run("should classify a record that disappeared as not found", func(t *testing.T) {
+		server := cloudflaretest.NewServer()
+		defer server.Close()
+		zoneID := server.AddZone("example.com")
+		provider := NewCloudflare(server.Client())
+		record := models.Record{Record: "www.example.com", Type: "A", Target: "192.0.2.1", Ttl: 3600}
+
+		if _, err := provider.UpdateRecord(context.Background(), zoneID, "missing-record-id", record); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Expected ErrRecordNotFound on update, got: %v", err)
+		}
+		if _, err := provider.DeleteRecord(context.Background(), zoneID, "missing-record-id", record); !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Expected ErrRecordNotFound on delete, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"yaca/models"
+)
+
+// Memory is a DNSProvider keeping its zones and records in memory. It is safe
+// for concurrent use.
+type Memory struct {
+	mu      sync.Mutex
+	zones   map[string]string
+	records map[string][]models.RecordData
+	nextID  int
+}
+
+// NewMemory returns an in-memory provider with the given zones
+func NewMemory(zoneNames ...string) *Memory {
+	m := &Memory{
+		zones:   make(map[string]string),
+		records: make(map[string][]models.RecordData),
+	}
+	for _, zoneName := range zoneNames {
+		m.AddZone(zoneName)
+	}
+	return m
+}
+
+// AddZone adds an empty zone, if it does not exist yet, and returns its ID
+func (m *Memory) AddZone(zoneName string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := strings.ToLower(zoneName)
+	if zoneID, ok := m.zones[key]; ok {
+		return zoneID
+	}
+
+	zoneID := m.newID("zone")
+	m.zones[key] = zoneID
+	m.records[zoneID] = nil
+	return zoneID
+}
+
+// Records returns a copy of every record of the zone, in creation order
+func (m *Memory) Records(zoneID string) []models.RecordData {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]models.RecordData(nil), m.records[zoneID]...)
+}
+
+// GetZoneIDByName returns the ID of the zone with the given name
+func (m *Memory) GetZoneIDByName(ctx context.Context, zoneName string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	zoneID, ok := m.zones[strings.ToLower(zoneName)]
+	if !ok {
+		return "", fmt.Errorf("%w: no zone found with name: %s", ErrZoneNotFound, zoneName)
+	}
+	return zoneID, nil
+}
+
+// ListRecords returns every record with the given name and type
+func (m *Memory) ListRecords(ctx context.Context, zoneID, recordName, recordType string) ([]models.RecordData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.records[zoneID]
+	if !ok {
+		return nil, m.zoneNotFound(zoneID)
+	}
+
+	var records []models.RecordData
+	for _, record := range existing {
+		if strings.EqualFold(record.Record.Record, recordName) && (recordType == "" || record.Record.Type == recordType) {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// GetRecords returns the records matching the given record, with the same
+// rules as Cloudflare.GetRecords
+func (m *Memory) GetRecords(ctx context.Context, zoneID string, record models.Record) ([]models.RecordData, error) {
+	records, err := m.ListRecords(ctx, zoneID, record.Record, record.Type)
+	if err != nil {
+		return nil, err
+	}
+	return filterByContent(records, record), nil
+}
+
+// CreateRecord adds the record to the zone and returns its new ID
+func (m *Memory) CreateRecord(ctx context.Context, zoneID string, record models.Record) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.records[zoneID]; !ok {
+		return "", m.zoneNotFound(zoneID)
+	}
+
+	recordID := m.newID("record")
+	m.records[zoneID] = append(m.records[zoneID], models.RecordData{ZoneID: zoneID, RecordID: recordID, Record: record})
+	return recordID, nil
+}
+
+// UpdateRecord replaces the record with the given ID
+func (m *Memory) UpdateRecord(ctx context.Context, zoneID, recordID string, record models.Record) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, err := m.find(zoneID, recordID)
+	if err != nil {
+		return false, err
+	}
+	m.records[zoneID][i].Record = record
+	return true, nil
+}
+
+// DeleteRecord removes the record with the given ID
+func (m *Memory) DeleteRecord(ctx context.Context, zoneID, recordID string, record models.Record) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, err := m.find(zoneID, recordID)
+	if err != nil {
+		return false, err
+	}
+	m.records[zoneID] = append(m.records[zoneID][:i], m.records[zoneID][i+1:]...)
+	return true, nil
+}
+
+// find returns the index of the record with the given ID in its zone
+func (m *Memory) find(zoneID, recordID string) (int, error) {
+	records, ok := m.records[zoneID]
+	if !ok {
+		return 0, m.zoneNotFound(zoneID)
+	}
+	for i, record := range records {
+		if record.RecordID == recordID {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: no record found with ID: %s", ErrRecordNotFound, recordID)
+}
+
+// zoneNotFound returns the error for an unknown zone ID
+func (m *Memory) zoneNotFound(zoneID string) error {
+	return fmt.Errorf("%w: no zone found with ID: %s", ErrZoneNotFound, zoneID)
+}
+
+// newID returns a new unique ID with the given prefix
+func (m *Memory) newID(prefix string) string {
+	m.nextID++
+	return fmt.Sprintf("%s-%d", prefix, m.nextID)
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"yaca/models"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	memory := NewMemory("example.com")
+
+	zoneID, err := memory.GetZoneIDByName(ctx, "Example.com")
+	if err != nil {
+		t.Fatalf("GetZoneIDByName() returned an error: %v", err)
+	}
+
+	t.Run("should report unknown zones", func(t *testing.T) {
+		_, err := memory.GetZoneIDByName(ctx, "example.net")
+		if !errors.Is(err, ErrZoneNotFound) {
+			t.Errorf("Expected ErrZoneNotFound, got: %v", err)
+		}
+	})
+
+	t.Run("should create, find, update and delete records", func(t *testing.T) {
+		txt := models.Record{Record: "example.com", Type: "TXT", Target: "v=spf1 -all", Ttl: 3600}
+		other := models.Record{Record: "example.com", Type: "TXT", Target: "verification=1", Ttl: 3600}
+
+		recordID, err := memory.CreateRecord(ctx, zoneID, txt)
+		if err != nil {
+			t.Fatalf("CreateRecord() returned an error: %v", err)
+		}
+		if _, err := memory.CreateRecord(ctx, zoneID, other); err != nil {
+			t.Fatalf("CreateRecord() returned an error: %v", err)
+		}
+
+		all, _ := memory.ListRecords(ctx, zoneID, "EXAMPLE.com", "TXT")
+		if len(all) != 2 {
+			t.Errorf("Expected 2 TXT records, got %d", len(all))
+		}
+		matches, _ := memory.GetRecords(ctx, zoneID, txt)
+		if len(matches) != 1 || matches[0].RecordID != recordID {
+			t.Errorf("Expected GetRecords() to match on content, got: %+v", matches)
+		}
+
+		txt.Ttl = 300
+		if _, err := memory.UpdateRecord(ctx, zoneID, recordID, txt); err != nil {
+			t.Fatalf("UpdateRecord() returned an error: %v", err)
+		}
+		matches, _ = memory.GetRecords(ctx, zoneID, txt)
+		if len(matches) != 1 || matches[0].Record.Ttl != 300 {
+			t.Errorf("Expected the record to be updated, got: %+v", matches)
+		}
+
+		if _, err := memory.DeleteRecord(ctx, zoneID, recordID, txt); err != nil {
+			t.Fatalf("DeleteRecord() returned an error: %v", err)
+		}
+		if records := memory.Records(zoneID); len(records) != 1 || records[0].Record.Target != "verification=1" {
+			t.Errorf("Expected only the other record to remain, got: %+v", records)
+		}
+
+		_, err = memory.DeleteRecord(ctx, zoneID, recordID, txt)
+		if !errors.Is(err, ErrRecordNotFound) {
+			t.Errorf("Expected ErrRecordNotFound, got: %v", err)
+		}
+	})
+
+	t.Run("should stop when the context is done", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := memory.CreateRecord(canceled, zoneID, models.Record{Record: "www.example.com", Type: "A", Target: "192.0.2.1"})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got: %v", err)
+		}
+	})
+}
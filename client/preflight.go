@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"yaca/pkg/logger"
+
+	"github.com/cloudflare/cloudflare-go/v4"
+	"github.com/cloudflare/cloudflare-go/v4/dns"
+	"github.com/cloudflare/cloudflare-go/v4/packages/pagination"
+	"github.com/cloudflare/cloudflare-go/v4/user"
+)
+
+// permissionDNSEdit is the zone permission reported by the API when the
+// credentials may edit DNS records
+const permissionDNSEdit = "#dns_records:edit"
+
+// Preflighter is implemented by providers that can check their credentials
+// before any change is made. Preflight returns the IDs of the zones it
+// checked, keyed by zone name, so that they need not be looked up again.
+type Preflighter interface {
+	Preflight(ctx context.Context, zoneNames []string, write bool) (map[string]string, error)
+}
+
+var _ Preflighter = (*Cloudflare)(nil)
+
+// TokenStatus is the state of an API token as reported by the API
+type TokenStatus struct {
+	ID        string
+	Status    string
+	ExpiresOn time.Time
+	NotBefore time.Time
+}
+
+// ZoneAccess describes what the credentials may do on a zone
+type ZoneAccess struct {
+	ZoneID  string
+	DNSRead bool
+	DNSEdit bool
+	// EditChecked is false when the API did not report the permissions of
+	// the zone, so DNSEdit could not be confirmed
+	EditChecked bool
+}
+
+// AuthMode returns the authentication scheme of the provider
+func (c *Cloudflare) AuthMode() AuthMode {
+	return c.mode
+}
+
+// VerifyToken calls the token verification endpoint and returns an error
+// wrapping ErrAuth unless the token is active and within its validity period
+func (c *Cloudflare) VerifyToken(ctx context.Context) (TokenStatus, error) {
+	if c.mode != AuthModeToken {
+		return TokenStatus{}, fmt.Errorf("%w: token verification requires an API token, not a %s", ErrAuth, c.mode)
+	}
+
+	response, err := withRetry(ctx, "verify token", isTransient, func(int) (*user.TokenVerifyResponse, error) {
+		return c.api.User.Tokens.Verify(ctx)
+	})
+	if err != nil {
+		return TokenStatus{}, fmt.Errorf("failed to verify API token: %w", classifyError(err))
+	}
+
+	status := TokenStatus{
+		ID:        response.ID,
+		Status:    string(response.Status),
+		ExpiresOn: response.ExpiresOn,
+		NotBefore: response.NotBefore,
+	}
+
+	now := time.Now()
+	switch {
+	case status.Status != string(user.TokenVerifyResponseStatusActive):
+		return status, fmt.Errorf("%w: API token is %s", ErrAuth, status.Status)
+	case !status.ExpiresOn.IsZero() && status.ExpiresOn.Before(now):
+		return status, fmt.Errorf("%w: API token expired on %s", ErrAuth, status.ExpiresOn.Format(time.RFC3339))
+	case !status.NotBefore.IsZero() && status.NotBefore.After(now):
+		return status, fmt.Errorf("%w: API token is not valid before %s", ErrAuth, status.NotBefore.Format(time.RFC3339))
+	}
+	return status, nil
+}
+
+// CheckZoneAccess checks that the credentials can read the zone and its DNS
+// records, and edit them when write is set, naming the missing permission
+// in the error otherwise. Editing is only checked when the API reports the
+// permissions of the zone; nothing is written to find out.
+func (c *Cloudflare) CheckZoneAccess(ctx context.Context, zoneName string, write bool) (ZoneAccess, error) {
+	zone, err := c.findZone(ctx, zoneName)
+	if errors.Is(err, ErrZoneNotFound) {
+		return ZoneAccess{}, fmt.Errorf("%w: zone %s is not visible to these credentials; check that it exists and that they have the Zone Read permission on it", ErrZoneNotFound, zoneName)
+	}
+	if err != nil {
+		return ZoneAccess{}, err
+	}
+
+	access := ZoneAccess{ZoneID: zone.ID}
+	_, err = withRetry(ctx, "list DNS records", isTransient, func(int) (*pagination.V4PagePaginationArray[dns.RecordResponse], error) {
+		return c.api.DNS.Records.List(ctx, dns.RecordListParams{
+			ZoneID:  cloudflare.F(zone.ID),
+			PerPage: cloudflare.F(float64(5)),
+		})
+	})
+	if err != nil {
+		err = classifyError(err)
+		if errors.Is(err, ErrAuth) {
+			return access, fmt.Errorf("%w: missing the DNS Read permission on zone %s", ErrAuth, zoneName)
+		}
+		return access, fmt.Errorf("failed to list DNS records: %w", err)
+	}
+	access.DNSRead = true
+
+	if len(zone.Permissions) > 0 {
+		access.EditChecked = true
+		access.DNSEdit = slices.Contains(zone.Permissions, permissionDNSEdit)
+	}
+	if write && access.EditChecked && !access.DNSEdit {
+		return access, fmt.Errorf("%w: missing the DNS Edit permission on zone %s", ErrAuth, zoneName)
+	}
+	return access, nil
+}
+
+// Preflight verifies the API token, unless authenticating with a Global API
+// Key, and checks access to each zone, so that under-scoped credentials are
+// reported before any change is made. A token that cannot be verified, such
+// as an account-owned token, which the user endpoint rejects, is only
+// reported when the zones are accessible.
+func (c *Cloudflare) Preflight(ctx context.Context, zoneNames []string, write bool) (map[string]string, error) {
+	var tokenErr error
+	if c.mode == AuthModeToken {
+		status, err := c.VerifyToken(ctx)
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, err
+		}
+		tokenErr = err
+		if err == nil {
+			logger.Debug("API token verified",
+				slog.String("status", status.Status))
+		}
+	}
+
+	zoneIDs := make(map[string]string)
+	for _, zoneName := range zoneNames {
+		access, err := c.CheckZoneAccess(ctx, zoneName, write)
+		if err != nil {
+			if tokenErr != nil {
+				return nil, fmt.Errorf("%w (token verification also failed: %v)", err, tokenErr)
+			}
+			return nil, err
+		}
+		if write && !access.EditChecked {
+			logger.Warn("DNS Edit permission could not be checked, the API did not report the zone permissions",
+				slog.String("zone_name", zoneName))
+		}
+		zoneIDs[zoneName] = access.ZoneID
+	}
+
+	if tokenErr != nil {
+		logger.Warn("API token could not be verified, continuing as the zones are accessible",
+			slog.String("error", tokenErr.Error()))
+	}
+	return zoneIDs, nil
+}
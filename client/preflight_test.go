@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"yaca/pkg/cloudflaretest"
+)
+
+func TestPreflight(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		setup       func(server *cloudflaretest.Server, zoneID string)
+		write       bool
+		wantErr     error
+		wantMessage string
+	}{
+		{
+			name: "should pass with an active token and DNS Edit",
+			setup: func(server *cloudflaretest.Server, zoneID string) {
+				server.SetZonePermissions(zoneID, "#zone:read", "#dns_records:read", "#dns_records:edit")
+			},
+			write: true,
+		},
+		{
+			name:  "should pass when the API does not report permissions",
+			setup: func(server *cloudflaretest.Server, zoneID string) {},
+			write: true,
+		},
+		{
+			name: "should reject an expired token",
+			setup: func(server *cloudflaretest.Server, zoneID string) {
+				server.SetToken(cloudflaretest.Token{ID: "token-id", Status: "active", ExpiresOn: time.Now().Add(-time.Hour)})
+				server.Fail(cloudflaretest.Fault{Path: "/zones", Status: 403, Code: 9109, Message: "Invalid access token"})
+			},
+			wantErr:     ErrAuth,
+			wantMessage: "API token expired",
+		},
+		{
+			name: "should reject a disabled token",
+			setup: func(server *cloudflaretest.Server, zoneID string) {
+				server.SetToken(cloudflaretest.Token{ID: "token-id", Status: "disabled"})
+				server.Fail(cloudflaretest.Fault{Path: "/zones", Status: 403, Code: 9109, Message: "Invalid access token"})
+			},
+			wantErr:     ErrAuth,
+			wantMessage: "API token is disabled",
+		},
+		{
+			name: "should continue when the token cannot be verified but the zone is accessible",
+			setup: func(server *cloudflaretest.Server, zoneID string) {
+				server.Fail(cloudflaretest.Fault{Path: "/user/tokens/verify", Status: 401, Code: 1000, Message: "Invalid API Token"})
+			},
+			write: true,
+		},
+		{
+			name: "should name the missing DNS Edit permission",
+			setup: func(server *cloudflaretest.Server, zoneID string) {
+				server.SetZonePermissions(zoneID, "#zone:read", "#dns_records:read")
+			},
+			write:       true,
+			wantErr:     ErrAuth,
+			wantMessage: "missing the DNS Edit permission",
+		},
+		{
+			name: "should not require DNS Edit for read-only runs",
+			setup: func(server *cloudflaretest.Server, zoneID string) {
+				server.SetZonePermissions(zoneID, "#zone:read", "#dns_records:read")
+			},
+		},
+		{
+			name: "should name the missing DNS Read permission",
+			setup: func(server *cloudflaretest.Server, zoneID string) {
+				server.Fail(cloudflaretest.Fault{Path: "/zones/*/dns_records", Status: 403, Code: 10000, Message: "Authentication error"})
+			},
+			wantErr:     ErrAuth,
+			wantMessage: "missing the DNS Read permission",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := cloudflaretest.NewServer()
+			defer server.Close()
+			zoneID := server.AddZone("example.com")
+			test.setup(server, zoneID)
+
+			zoneIDs, err := NewCloudflare(server.Client()).Preflight(ctx, []string{"example.com"}, test.write)
+
+			if test.wantErr == nil && err != nil {
+				t.Errorf("Preflight() returned an error: %v", err)
+			}
+			if test.wantErr == nil && zoneIDs["example.com"] != zoneID {
+				t.Errorf("Expected the zone ID to be returned, got: %v", zoneIDs)
+			}
+			if test.wantErr != nil && (!errors.Is(err, test.wantErr) || !strings.Contains(err.Error(), test.wantMessage)) {
+				t.Errorf("Expected an error containing %q, got: %v", test.wantMessage, err)
+			}
+		})
+	}
+
+	t.Run("should report zones the credentials cannot see", func(t *testing.T) {
+		server := cloudflaretest.NewServer()
+		defer server.Close()
+
+		_, err := NewCloudflare(server.Client()).Preflight(ctx, []string{"example.com"}, true)
+		if !errors.Is(err, ErrZoneNotFound) || !strings.Contains(err.Error(), "Zone Read permission") {
+			t.Errorf("Expected a zone visibility error, got: %v", err)
+		}
+	})
+
+	t.Run("should skip token verification with a Global API Key", func(t *testing.T) {
+		server := cloudflaretest.NewServer()
+		defer server.Close()
+		server.AddZone("example.com")
+		server.SetToken(cloudflaretest.Token{Status: "disabled"})
+
+		provider := NewCloudflare(server.Client())
+		provider.mode = AuthModeGlobalKey
+
+		if _, err := provider.Preflight(ctx, []string{"example.com"}, true); err != nil {
+			t.Errorf("Preflight() returned an error: %v", err)
+		}
+		for _, request := range server.Requests() {
+			if request.Path == "/user/tokens/verify" {
+				t.Errorf("Expected the token not to be verified")
+			}
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+
+	"yaca/models"
+	"yaca/pkg/reconcile"
+)
+
+// DNSProvider manages the records of the zones of a DNS provider. Cloudflare
+// talks to the Cloudflare API and Memory keeps the zones in memory, for tests
+// and tools embedding yaca.
+type DNSProvider interface {
+	// GetZoneIDByName returns the ID of the zone with the given name, or an
+	// error wrapping ErrZoneNotFound if there is none
+	GetZoneIDByName(ctx context.Context, zoneName string) (string, error)
+	// ListRecords returns every record with the given name and type; an empty
+	// type matches records of any type
+	ListRecords(ctx context.Context, zoneID, recordName, recordType string) ([]models.RecordData, error)
+	// GetRecords returns the records matching the given record, as described
+	// on Cloudflare.GetRecords
+	GetRecords(ctx context.Context, zoneID string, record models.Record) ([]models.RecordData, error)
+	// CreateRecord creates the record and returns the ID assigned to it
+	CreateRecord(ctx context.Context, zoneID string, record models.Record) (string, error)
+	// UpdateRecord replaces the record with the given ID
+	UpdateRecord(ctx context.Context, zoneID, recordID string, record models.Record) (bool, error)
+	// DeleteRecord deletes the record with the given ID
+	DeleteRecord(ctx context.Context, zoneID, recordID string, record models.Record) (bool, error)
+}
+
+var (
+	_ DNSProvider = (*Cloudflare)(nil)
+	_ DNSProvider = (*Memory)(nil)
+)
+
+// filterByContent keeps the records whose content matches the given record
+// when it is of a multi-value type with a target, and all records otherwise
+func filterByContent(records []models.RecordData, record models.Record) []models.RecordData {
+	if !models.IsMultiValueType(record.Type) || record.Target == "" {
+		return records
+	}
+
+	key := reconcile.ContentKey(record)
+	var matches []models.RecordData
+	for _, existing := range records {
+		if reconcile.ContentKey(existing.Record) == key {
+			matches = append(matches, existing)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"yaca/pkg/config"
+	"yaca/pkg/logger"
+
+	"github.com/cloudflare/cloudflare-go/v4"
+)
+
+// retryPolicy controls how failed Cloudflare API calls are retried
+type retryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// maxRetryAfter is the longest wait asked by Retry-After that is honoured;
+// beyond it, or beyond the deadline of the context, the call fails at once
+const maxRetryAfter = 5 * time.Minute
+
+// defaultRetryPolicy is used when the configuration has not been loaded
+var defaultRetryPolicy = retryPolicy{
+	MaxAttempts: 3,
+	Backoff:     time.Second,
+	MaxBackoff:  30 * time.Second,
+}
+
+// currentRetryPolicy returns the retry policy from the configuration
+func currentRetryPolicy() retryPolicy {
+	if config.AppConfig == nil {
+		return defaultRetryPolicy
+	}
+	return retryPolicy{
+		MaxAttempts: config.AppConfig.RetryMaxAttempts,
+		Backoff:     config.AppConfig.RetryBackoff,
+		MaxBackoff:  config.AppConfig.RetryMaxBackoff,
+	}
+}
+
+// sleep waits for the delay or until the context is done
+var sleep = func(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// withRetry runs call until it succeeds, fails with an error that retryable
+// rejects, or runs out of attempts. The attempt number, starting at 1, is
+// passed to call.
+func withRetry[T any](ctx context.Context, operation string, retryable func(error) bool, call func(attempt int) (T, error)) (T, error) {
+	policy := currentRetryPolicy()
+
+	for attempt := 1; ; attempt++ {
+		result, err := call(attempt)
+		if err == nil || attempt >= policy.MaxAttempts || !retryable(err) {
+			return result, err
+		}
+
+		delay := policy.delay(attempt, err)
+		if delay > maxRetryAfter || pastDeadline(ctx, delay) {
+			logger.Warn("Cloudflare API asked to wait longer than allowed, not retrying",
+				slog.String("operation", operation),
+				slog.Int("attempt", attempt),
+				slog.Duration("delay", delay),
+				slog.String("error", err.Error()))
+			return result, err
+		}
+
+		logger.Warn("Cloudflare API call failed, retrying",
+			slog.String("operation", operation),
+			slog.Int("attempt", attempt),
+			slog.Int("max_attempts", policy.MaxAttempts),
+			slog.Duration("delay", delay),
+			slog.String("error", err.Error()))
+
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			return result, fmt.Errorf("%w (last error: %w)", sleepErr, err)
+		}
+	}
+}
+
+// delay returns how long to wait after the given failed attempt: the
+// exponential backoff, or longer if the API asked for it with Retry-After
+func (p retryPolicy) delay(attempt int, err error) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	if retryAfter, ok := retryAfterDelay(err); ok && retryAfter > delay {
+		return retryAfter
+	}
+	return delay
+}
+
+// pastDeadline reports whether waiting for the delay would outlast the
+// deadline of the context
+func pastDeadline(ctx context.Context, delay time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return ok && time.Until(deadline) < delay
+}
+
+// retryAfterDelay reads the Retry-After header of a failed API response,
+// given either in seconds or as an HTTP date
+func retryAfterDelay(err error) (time.Duration, bool) {
+	var apiErr *cloudflare.Error
+	if !errors.As(err, &apiErr) || apiErr.Response == nil {
+		return 0, false
+	}
+
+	header := apiErr.Response.Header.Get("Retry-After")
+	if header == "" {
+		return 0, false
+	}
+	if seconds, parseErr := strconv.Atoi(header); parseErr == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, parseErr := http.ParseTime(header); parseErr == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+// isTransient reports whether a failed call may succeed if repeated: the
+// API was rate limited or failed with a 5xx error, or the request did not
+// get a response at all
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *cloudflare.Error
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+}
+
+// isRateLimited reports whether the API rejected the call because of rate
+// limiting, in which case the request was not applied and can be repeated
+// even if it is not idempotent
+func isRateLimited(err error) bool {
+	var apiErr *cloudflare.Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests
+}
+
+// isNotFound reports whether the API answered that the resource does not exist
+func isNotFound(err error) bool {
+	var apiErr *cloudflare.Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
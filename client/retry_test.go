@@ -0,0 +1,212 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+	"yaca/models"
+	"yaca/pkg/cloudflaretest"
+)
+
+// recordSleeps replaces sleep for the duration of the test and returns the
+// delays it was called with
+func recordSleeps(t *testing.T) *[]time.Duration {
+	var delays []time.Duration
+	original := sleep
+	sleep = func(ctx context.Context, delay time.Duration) error {
+		delays = append(delays, delay)
+		return nil
+	}
+	t.Cleanup(func() { sleep = original })
+	return &delays
+}
+
+// failingHandler answers the first failures requests with the given status
+// and succeeds afterwards, counting the requests it receives
+func failingHandler(calls *int32, failures int32, status int, header http.Header, success string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.AddInt32(calls, 1) <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(status)
+			fmt.Fprintln(w, `{"result": null, "success": false, "errors": [{"code": 10000, "message": "test error"}], "messages": []}`)
+			return
+		}
+		fmt.Fprintln(w, success)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	record := models.Record{Record: "www.example.com", Type: "A", Target: "192.0.2.1", Ttl: 3600}
+
+	t.Run("should retry lookups on server errors", func(t *testing.T) {
+		delays := recordSleeps(t)
+		var calls int32
+		server, cfClient := setupMockServer(t, failingHandler(&calls, 2, http.StatusBadGateway, nil,
+			`{"result": [], "result_info": {"page": 1, "per_page": 100, "count": 0, "total_count": 0}, "success": true, "errors": [], "messages": []}`))
+		defer server.Close()
+		provider := NewCloudflare(cfClient)
+
+		_, err := provider.ListRecords(context.Background(), "test-zone-id", "www.example.com", "A")
+
+		if err != nil {
+			t.Errorf("ListRecords() returned an error: %v", err)
+		}
+		if calls != 3 {
+			t.Errorf("Expected 3 attempts, got %d", calls)
+		}
+		if len(*delays) != 2 || (*delays)[0] != time.Second || (*delays)[1] != 2*time.Second {
+			t.Errorf("Expected exponential backoff, got %v", *delays)
+		}
+	})
+
+	t.Run("should honour Retry-After when rate limited", func(t *testing.T) {
+		delays := recordSleeps(t)
+		var calls int32
+		server, cfClient := setupMockServer(t, failingHandler(&calls, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"7"}},
+			`{"result": {"id": "new-record-id"}, "success": true, "errors": [], "messages": []}`))
+		defer server.Close()
+		provider := NewCloudflare(cfClient)
+
+		recordID, err := provider.CreateRecord(context.Background(), "test-zone-id", record)
+
+		if err != nil || recordID != "new-record-id" {
+			t.Errorf("CreateRecord() = %q, %v", recordID, err)
+		}
+		if len(*delays) != 1 || (*delays)[0] != 7*time.Second {
+			t.Errorf("Expected to wait for Retry-After, got %v", *delays)
+		}
+	})
+
+	t.Run("should not retry creation on server errors", func(t *testing.T) {
+		recordSleeps(t)
+		var calls int32
+		server, cfClient := setupMockServer(t, failingHandler(&calls, 1, http.StatusBadGateway, nil,
+			`{"result": {"id": "new-record-id"}, "success": true, "errors": [], "messages": []}`))
+		defer server.Close()
+		provider := NewCloudflare(cfClient)
+
+		_, err := provider.CreateRecord(context.Background(), "test-zone-id", record)
+
+		if !errors.Is(err, ErrServer) {
+			t.Errorf("Expected ErrServer, got: %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("Expected a single attempt, got %d", calls)
+		}
+	})
+
+	t.Run("should treat a retried delete of a missing record as done", func(t *testing.T) {
+		recordSleeps(t)
+		var calls int32
+		server, cfClient := setupMockServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			status := http.StatusServiceUnavailable
+			if atomic.AddInt32(&calls, 1) > 1 {
+				status = http.StatusNotFound
+			}
+			w.WriteHeader(status)
+			fmt.Fprintln(w, `{"result": null, "success": false, "errors": [{"code": 81044, "message": "Record does not exist."}], "messages": []}`)
+		}))
+		defer server.Close()
+		provider := NewCloudflare(cfClient)
+
+		success, err := provider.DeleteRecord(context.Background(), "test-zone-id", "test-record-id", record)
+
+		if err != nil || !success {
+			t.Errorf("DeleteRecord() = %t, %v", success, err)
+		}
+	})
+
+	t.Run("should stop retrying when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var calls int32
+		server, cfClient := setupMockServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			failingHandler(&calls, 10, http.StatusBadGateway, nil, "")(w, r)
+			cancel()
+		}))
+		defer server.Close()
+		provider := NewCloudflare(cfClient)
+
+		_, err := provider.UpdateRecord(ctx, "test-zone-id", "test-record-id", record)
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got: %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("Expected a single attempt, got %d", calls)
+		}
+	})
+
+	t.Run("should give up after the maximum number of attempts", func(t *testing.T) {
+		recordSleeps(t)
+		var calls int32
+		server, cfClient := setupMockServer(t, failingHandler(&calls, 10, http.StatusInternalServerError, nil, ""))
+		defer server.Close()
+		provider := NewCloudflare(cfClient)
+
+		_, err := provider.UpdateRecord(context.Background(), "test-zone-id", "test-record-id", record)
+
+		if !errors.Is(err, ErrServer) {
+			t.Errorf("Expected ErrServer, got: %v", err)
+		}
+		if calls != int32(defaultRetryPolicy.MaxAttempts) {
+			t.Errorf("Expected %d attempts, got %d", defaultRetryPolicy.MaxAttempts, calls)
+		}
+	})
+
+	t.Run("should not wait for a Retry-After beyond the deadline", func(t *testing.T) {
+		delays := recordSleeps(t)
+		server := cloudflaretest.NewServer()
+		defer server.Close()
+		zoneID := server.AddZone("example.com")
+		server.RateLimit(1, time.Minute)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_, err := NewCloudflare(server.Client()).ListRecords(ctx, zoneID, "www.example.com", "A")
+
+		if !errors.Is(err, ErrRateLimited) {
+			t.Errorf("Expected ErrRateLimited, got: %v", err)
+		}
+		if len(*delays) != 0 {
+			t.Errorf("Expected not to wait, got %v", *delays)
+		}
+	})
+
+	t.Run("should not wait for a Retry-After beyond the cap", func(t *testing.T) {
+		delays := recordSleeps(t)
+		server := cloudflaretest.NewServer()
+		defer server.Close()
+		zoneID := server.AddZone("example.com")
+		server.RateLimit(1, maxRetryAfter+time.Minute)
+
+		_, err := NewCloudflare(server.Client()).ListRecords(context.Background(), zoneID, "www.example.com", "A")
+
+		if !errors.Is(err, ErrRateLimited) {
+			t.Errorf("Expected ErrRateLimited, got: %v", err)
+		}
+		if len(*delays) != 0 {
+			t.Errorf("Expected not to wait, got %v", *delays)
+		}
+	})
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := retryPolicy{MaxAttempts: 10, Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	err := errors.New("connection reset")
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, want := range expected {
+		if got := policy.delay(i+1, err); got != want {
+			t.Errorf("delay(%d) is incorrect, got: %v, want: %v", i+1, got, want)
+		}
+	}
+}
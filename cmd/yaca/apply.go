@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"yaca/client"
+	"yaca/models"
+	"yaca/pkg/apply"
+	"yaca/pkg/logger"
+	"yaca/pkg/manifest"
+	"yaca/pkg/utils"
+)
+
+// runApply applies every record of a manifest, resolving each zone once, and
+// returns a non-zero exit code if any record fails. In dry-run mode the
+// planned changes are printed instead. The provider is created once the
+// manifest has been loaded, and the zones resolved by the preflight check are
+// not looked up again.
+func runApply(ctx context.Context, newProvider func() (client.DNSProvider, error), applyArgs models.ApplyArgs, dryRun, skipPreflight bool) int {
+	loaded, err := manifest.Load(applyArgs.File)
+	if err != nil {
+		err = fmt.Errorf("%w: %w", utils.ErrInvalidArguments, err)
+	}
+	utilsHandleError(err, "Failed to load manifest",
+		slog.String("file", applyArgs.File))
+	if err != nil {
+		return utils.ExitCode(err)
+	}
+
+	provider, err := configureProvider(newProvider)
+	if err != nil {
+		return utils.ExitCode(err)
+	}
+
+	var zoneIDs map[string]string
+	if !skipPreflight {
+		var zoneNames []string
+		for _, zone := range loaded.Zones {
+			zoneNames = append(zoneNames, zone.Name)
+		}
+		zoneIDs, err = preflight(ctx, provider, zoneNames, !dryRun)
+		utilsHandleError(err, "Preflight check failed")
+		if err != nil {
+			return utils.ExitCode(err)
+		}
+	}
+
+	results := apply.Manifest(ctx, provider, loaded, zoneIDs, dryRun)
+
+	if dryRun {
+		var changes []models.Change
+		for _, result := range results {
+			changes = append(changes, result.Changes...)
+		}
+		writePlan(os.Stdout, changes)
+	} else {
+		writeApplyResults(os.Stdout, results)
+	}
+
+	if err := utilsWriteGitHubStepSummary(stepSummary(results, dryRun)); err != nil {
+		logger.Warn("Failed to write job summary",
+			slog.String("error", err.Error()))
+	}
+
+	// The first failed record decides the exit code
+	var failed error
+	for _, result := range results {
+		if result.Err != nil {
+			failed = result.Err
+			break
+		}
+	}
+
+	if dryRun {
+		if failed != nil {
+			writeErrorCode(failed)
+		}
+		return utils.ExitCode(failed)
+	}
+
+	outputs, err := applyOutputs(results)
+	if err == nil {
+		if failed != nil {
+			outputs["error_code"] = utils.ErrorCode(failed)
+		}
+		err = utilsWriteGitHubOutputs(outputs)
+	}
+	if err != nil {
+		logger.Warn("Failed to write step outputs",
+			slog.String("error", err.Error()))
+	}
+	return utils.ExitCode(failed)
+}
+
+// writeApplyResults prints one row per manifest entry with the changes made
+func writeApplyResults(w io.Writer, results []apply.Result) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ZONE\tRECORD\tTYPE\tRESULT")
+	for _, result := range results {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n",
+			logger.MaskValue("zone_name", result.Zone),
+			logger.MaskValue("record_name", result.Record),
+			result.Type,
+			result.Summary())
+	}
+	table.Flush()
+}
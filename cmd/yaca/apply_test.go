@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"yaca/client"
+	"yaca/models"
+	"yaca/pkg/apply"
+	"yaca/pkg/cloudflaretest"
+	"yaca/pkg/utils"
+)
+
+// writeManifest writes the manifest to a temporary file and returns its path
+func writeManifest(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "records.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	return path
+}
+
+func TestRunApply(t *testing.T) {
+	resetTestState()
+
+	path := writeManifest(t, `
+zones:
+  - name: example.com
+    records:
+      - name: www.example.com
+        type: A
+        content: 192.0.2.1
+      - name: www.example.com
+        type: A
+        content: 192.0.2.2
+      - name: example.com
+        type: MX
+        content: mail.example.com
+        priority: 10
+  - name: example.org
+    records:
+      - name: old.example.org
+        type: CNAME
+        delete: true
+`)
+
+	zoneLookups := 0
+	mockGetZoneIDByNameFunc = func(zoneName string) (string, error) {
+		zoneLookups++
+		return zoneName + "-id", nil
+	}
+	listCalls := 0
+	mockListRecordsOnZoneFunc = func(zoneID, recordName, recordType string) ([]models.RecordData, error) {
+		listCalls++
+		return nil, nil
+	}
+	mockDoesRecordExistOnZoneFunc = func(zoneID string, record models.Record) ([]models.RecordData, error) {
+		if record.Type == "CNAME" {
+			return []models.RecordData{{ZoneID: zoneID, RecordID: "old-id", Record: record}}, nil
+		}
+		return nil, nil
+	}
+	var created []models.Record
+	mockCreateRecordOnZoneFunc = func(zoneID string, record models.Record) (string, error) {
+		created = append(created, record)
+		return "new-record-id", nil
+	}
+	mockUpdateRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
+		return false, errors.New("should not be called")
+	}
+	var deleted []string
+	mockDeleteRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
+		deleted = append(deleted, recordID)
+		return true, nil
+	}
+
+	result := runApply(context.Background(), newMockProvider, models.ApplyArgs{File: path}, false, false)
+
+	if result != 0 {
+		t.Errorf("Expected exit code 0, got %d", result)
+	}
+	if zoneLookups != 2 {
+		t.Errorf("Expected each zone to be resolved once, got %d lookups", zoneLookups)
+	}
+	if listCalls != 1 {
+		t.Errorf("Expected A records to be reconciled as one set, got %d lookups", listCalls)
+	}
+	if len(created) != 3 {
+		t.Errorf("Expected 3 records to be created, got %d", len(created))
+	}
+	if len(created) == 3 && created[0].Ttl != 3600 {
+		t.Errorf("Expected the default TTL, got %v", created[0].Ttl)
+	}
+	if len(deleted) != 1 || deleted[0] != "old-id" {
+		t.Errorf("Expected old-id to be deleted, got %v", deleted)
+	}
+}
+
+func TestRunApplyReportsFailedRecords(t *testing.T) {
+	resetTestState()
+
+	path := writeManifest(t, `
+zones:
+  - name: example.com
+    records:
+      - name: www.example.com
+        type: CNAME
+        content: example.net
+      - name: example.com
+        type: MX
+        content: 192.0.2.1
+`)
+	mockGetZoneIDByNameFunc = func(zoneName string) (string, error) { return "test-zone-id", nil }
+	mockDoesRecordExistOnZoneFunc = func(zoneID string, record models.Record) ([]models.RecordData, error) { return nil, nil }
+	created := 0
+	mockCreateRecordOnZoneFunc = func(zoneID string, record models.Record) (string, error) {
+		created++
+		return "new-record-id", nil
+	}
+
+	result := runApply(context.Background(), newMockProvider, models.ApplyArgs{File: path}, false, false)
+
+	if result != utils.ExitValidation {
+		t.Errorf("Expected exit code %d, got %d", utils.ExitValidation, result)
+	}
+	if created != 1 {
+		t.Errorf("Expected the valid record to be applied, got %d creations", created)
+	}
+	if exitCalled {
+		t.Errorf("Exit was called unexpectedly")
+	}
+}
+
+func TestWriteApplyResults(t *testing.T) {
+	t.Setenv("DISABLE_LOG_MASKING", "true")
+
+	var out bytes.Buffer
+	writeApplyResults(&out, []apply.Result{
+		{Zone: "example.com", Record: "www.example.com", Type: "A", Changes: []models.Change{
+			{Operation: models.OperationCreated},
+			{Operation: models.OperationCreated},
+			{Operation: models.OperationDeleted},
+		}},
+		{Zone: "example.com", Record: "mail.example.com", Type: "MX", Err: fmt.Errorf("%w: no zone found with name: example.com", client.ErrZoneNotFound)},
+	})
+
+	table := out.String()
+	for _, want := range []string{"ZONE", "www.example.com", "created 2, deleted 1", "failed: zone_not_found"} {
+		if !strings.Contains(table, want) {
+			t.Errorf("Expected table to contain %q, got:\n%s", want, table)
+		}
+	}
+}
+
+func TestWriteApplyResultsMasksFailures(t *testing.T) {
+	t.Setenv("DISABLE_LOG_MASKING", "")
+
+	var out bytes.Buffer
+	writeApplyResults(&out, []apply.Result{
+		{Zone: "secret-zone.com", Record: "mail.secret-zone.com", Type: "MX", Err: fmt.Errorf("%w: no zone found with name: secret-zone.com", client.ErrZoneNotFound)},
+	})
+
+	table := out.String()
+	if strings.Contains(table, "secret-zone") {
+		t.Errorf("Expected the zone and record names to be masked, got:\n%s", table)
+	}
+	if !strings.Contains(table, "failed: zone_not_found") {
+		t.Errorf("Expected the error code of the failed entry, got:\n%s", table)
+	}
+}
+
+func TestRunApplyAgainstFakeServer(t *testing.T) {
+	resetTestState()
+	server := cloudflaretest.NewServer()
+	defer server.Close()
+	zoneID := server.AddZone("example.com")
+	server.AddRecord(zoneID, cloudflaretest.Record{Name: "www.example.com", Type: "A", Content: "192.0.2.9", TTL: 3600})
+	server.AddRecord(zoneID, cloudflaretest.Record{Name: "old.example.com", Type: "CNAME", Content: "example.net", TTL: 3600})
+
+	path := writeManifest(t, `
+zones:
+  - name: example.com
+    records:
+      - name: www.example.com
+        type: A
+        content: 192.0.2.1
+      - name: www.example.com
+        type: A
+        content: 192.0.2.2
+      - name: old.example.com
+        type: CNAME
+        delete: true
+`)
+
+	newProvider := func() (client.DNSProvider, error) { return client.NewCloudflare(server.Client()), nil }
+	result := runApply(context.Background(), newProvider, models.ApplyArgs{File: path}, false, false)
+
+	if result != 0 {
+		t.Errorf("Expected exit code 0, got %d", result)
+	}
+
+	var contents []string
+	for _, record := range server.Records(zoneID) {
+		contents = append(contents, record.Name+" "+record.Type+" "+record.Content)
+	}
+	want := "www.example.com A 192.0.2.1,www.example.com A 192.0.2.2"
+	if got := strings.Join(contents, ","); got != want {
+		t.Errorf("Unexpected zone contents, got: %s, want: %s", got, want)
+	}
+}
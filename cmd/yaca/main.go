@@ -1,11 +1,18 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"yaca/client"
 	"yaca/models"
+	"yaca/pkg/apply"
 	"yaca/pkg/config"
 	"yaca/pkg/logger"
 	"yaca/pkg/utils"
@@ -16,22 +23,37 @@ var (
 	utilsParseArgs              = utils.ParseArgs
 	utilsValidateArgs           = utils.ValidateArgs
 	utilsHandleError            = utils.HandleError
-	clientGetZoneIDByName       = client.GetZoneIDByName
-	clientDoesRecordExistOnZone = client.DoesRecordExistOnZone
-	clientUpdateRecordOnZone    = client.UpdateRecordOnZone
-	clientCreateRecordOnZone    = client.CreateRecordOnZone
-	clientDeleteRecordOnZone    = client.DeleteRecordOnZone
+	utilsWriteGitHubOutputs     = utils.WriteGitHubOutputs
+	utilsWriteGitHubStepSummary = utils.WriteGitHubStepSummary
 )
 
-func run() int {
+// newCloudflareProvider returns the provider used by main, backed by the
+// Cloudflare API client configured from the environment
+func newCloudflareProvider() (client.DNSProvider, error) {
+	provider, err := client.NewCloudflareFromSource(client.EnvSource{})
+	if err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// run executes the command described by the arguments and returns its exit
+// code. newProvider creates the DNS provider the command talks to.
+func run(newProvider func() (client.DNSProvider, error)) int {
 	// Initialize configuration
-	config.Load()
+	_, configErr := config.Load()
 	
 	// Initialize logger
 	logger.Init()
 	logger.Info("Starting Yet Another Cloudflare Action",
 		slog.String("environment", config.AppConfig.Environment),
 		slog.String("log_level", config.AppConfig.LogLevel))
+
+	if configErr != nil {
+		err := fmt.Errorf("%w: %w", utils.ErrInvalidArguments, configErr)
+		utilsHandleError(err, "Invalid configuration")
+		return utils.ExitCode(err)
+	}
 	
 	// Try to load .env file, but don't fail if it doesn't exist
 	if err := utilsLoadEnv(); err != nil {
@@ -41,8 +63,36 @@ func run() int {
 	}
 
 	args := utilsParseArgs()
+
+	ctx, stop := operationContext(args.Timeout)
+	defer stop()
+
+	if args.VerifyToken != nil {
+		provider, err := configureProvider(newProvider)
+		if err != nil {
+			return utils.ExitCode(err)
+		}
+		return runVerifyToken(ctx, provider, args.ZoneName)
+	}
+
+	if args.Apply != nil {
+		return runApply(ctx, newProvider, *args.Apply, args.DryRun, args.SkipPreflight)
+	}
+	if args.Plan != nil {
+		args.DryRun = true
+		if args.Plan.File != "" {
+			return runApply(ctx, newProvider, models.ApplyArgs{File: args.Plan.File}, true, args.SkipPreflight)
+		}
+	}
+
 	err := utilsValidateArgs(&args)
+	if err != nil {
+		err = fmt.Errorf("%w: %w", utils.ErrInvalidArguments, err)
+	}
 	utilsHandleError(err, "Failed to validate arguments")
+	if err != nil {
+		return utils.ExitCode(err)
+	}
 
 	logger.Debug("Arguments validated",
 		slog.String("record_name", args.Record),
@@ -50,89 +100,94 @@ func run() int {
 		slog.Bool("delete", args.Delete),
 		slog.String("type", args.Type))
 
-	zoneID, err := clientGetZoneIDByName(args.ZoneName)
-	utilsHandleError(err, "Failed to get zone ID",
-		slog.String("zone_name", args.ZoneName))
+	// The provider is only created once the arguments are known to be valid,
+	// so that invalid arguments are reported before missing credentials
+	provider, err := configureProvider(newProvider)
+	if err != nil {
+		return utils.ExitCode(err)
+	}
+
+	var zoneIDs map[string]string
+	if !args.SkipPreflight {
+		zoneIDs, err = preflight(ctx, provider, []string{args.ZoneName}, !args.DryRun)
+		utilsHandleError(err, "Preflight check failed",
+			slog.String("zone_name", args.ZoneName))
+		if err != nil {
+			return utils.ExitCode(err)
+		}
+	}
+
+	// The preflight check resolves the zone already, when the provider has one
+	zoneID, ok := zoneIDs[args.ZoneName]
+	if !ok {
+		zoneID, err = provider.GetZoneIDByName(ctx, args.ZoneName)
+		utilsHandleError(err, "Failed to get zone ID",
+			slog.String("zone_name", args.ZoneName))
+		if err != nil {
+			return utils.ExitCode(err)
+		}
+	}
 
 	logger.Info("Zone retrieved",
 		slog.String("zone_id", zoneID), // Will be masked automatically
 		slog.String("zone_name", args.ZoneName))
 
-	recordID, err := clientDoesRecordExistOnZone(zoneID, args.Record)
-	utilsHandleError(err, "Failed to check record existence",
-		slog.String("zone_id", zoneID),
-		slog.String("record_name", args.Record))
-
-	record := models.Record{
-		Record: args.Record,
-		Proxy:  args.Proxy,
-		Target: args.Target,
-		Ttl:    args.Ttl,
-		Type:   args.Type,
-	}
-
-	if recordID != "" {
-		logger.Info("Record exists",
-			slog.String("record_name", args.Record),
-			slog.String("zone_name", args.ZoneName),
-			slog.String("record_id", recordID)) // Will be masked
-
-		if !args.Delete {
-			success, err := clientUpdateRecordOnZone(zoneID, recordID, record)
-			utilsHandleError(err, "Failed to update record",
-				slog.String("zone_id", zoneID),
-				slog.String("record_id", recordID))
-
-			if success {
-				logger.Info("Record updated successfully",
-					slog.String("record_name", args.Record),
-					slog.String("zone_name", args.ZoneName),
-					slog.String("operation", "update"))
-				return 0
-			}
-		} else {
-			success, err := clientDeleteRecordOnZone(zoneID, recordID, record)
-			utilsHandleError(err, "Failed to delete record",
-				slog.String("zone_id", zoneID),
-				slog.String("record_id", recordID))
-
-			if success {
-				logger.Info("Record deleted successfully",
-					slog.String("record_name", args.Record),
-					slog.String("zone_name", args.ZoneName),
-					slog.String("operation", "delete"))
-				return 0
-			}
+	changes, err := apply.Record(ctx, provider, zoneID, args, args.DryRun)
+	if args.DryRun {
+		writePlan(os.Stdout, changes)
+	}
+	if err != nil {
+		if errors.Is(err, client.ErrRecordNotFound) {
+			writeErrorCode(err)
+			return utils.ExitCode(err)
 		}
-	} else {
-		logger.Info("Record does not exist",
-			slog.String("record_name", args.Record),
-			slog.String("zone_name", args.ZoneName))
+		utilsHandleError(err, "Failed to apply record",
+			slog.String("zone_id", zoneID),
+			slog.String("record_name", args.Record))
+		return utils.ExitCode(err)
+	}
 
-		if args.Delete {
-			logger.Warn("Cannot delete non-existent record",
-				slog.String("record_name", args.Record),
-				slog.String("zone_name", args.ZoneName))
-			return 1
+	summary := stepSummary([]apply.Result{{Zone: args.ZoneName, Record: args.Record, Type: args.Type, Changes: changes}}, args.DryRun)
+	if err := utilsWriteGitHubStepSummary(summary); err != nil {
+		logger.Warn("Failed to write job summary",
+			slog.String("error", err.Error()))
+	}
+
+	if !args.DryRun {
+		err = utilsWriteGitHubOutputs(stepOutputs(zoneID, args.ZoneName, changes))
+		utilsHandleError(err, "Failed to write step outputs")
+		if err != nil {
+			return utils.ExitCode(err)
 		}
+	}
 
-		success, err := clientCreateRecordOnZone(zoneID, record)
-		utilsHandleError(err, "Failed to create record",
-			slog.String("zone_id", zoneID))
+	return 0
+}
 
-		if success {
-			logger.Info("Record created successfully",
-				slog.String("record_name", args.Record),
-				slog.String("zone_name", args.ZoneName),
-				slog.String("operation", "create"))
-			return 0
-		}
+// configureProvider creates the DNS provider, reporting a failure through the
+// error handler
+func configureProvider(newProvider func() (client.DNSProvider, error)) (client.DNSProvider, error) {
+	provider, err := newProvider()
+	utilsHandleError(err, "Failed to configure the Cloudflare client")
+	return provider, err
+}
+
+// operationContext returns the context bounding the whole run: it is
+// cancelled on SIGINT or SIGTERM, so cancelling the workflow stops in-flight
+// API calls, and after the timeout unless it is zero
+func operationContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	if timeout <= 0 {
+		return ctx, stop
 	}
 
-	logger.Error("Operation failed unexpectedly")
-	return 1
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
 }
 
 func main() {
-	os.Exit(run())
+	os.Exit(run(newCloudflareProvider))
 }
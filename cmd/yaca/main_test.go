@@ -1,11 +1,17 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"path/filepath"
 	"testing"
+	"time"
+	"yaca/client"
 	"yaca/models"
 	"yaca/pkg/config"
 	"yaca/pkg/logger"
+	"yaca/pkg/utils"
 )
 
 // Global mock variables
@@ -15,12 +21,42 @@ var (
 	mockValidateArgsFunc        func(*models.Args) error
 	mockHandleErrorFunc         func(error, string, ...any)
 	mockGetZoneIDByNameFunc     func(string) (string, error)
-	mockDoesRecordExistOnZoneFunc func(string, string) (string, error)
+	mockDoesRecordExistOnZoneFunc func(string, models.Record) ([]models.RecordData, error)
+	mockListRecordsOnZoneFunc   func(string, string, string) ([]models.RecordData, error)
 	mockUpdateRecordOnZoneFunc  func(string, string, models.Record) (bool, error)
-	mockCreateRecordOnZoneFunc  func(string, models.Record) (bool, error)
+	mockCreateRecordOnZoneFunc  func(string, models.Record) (string, error)
 	mockDeleteRecordOnZoneFunc  func(string, string, models.Record) (bool, error)
+	writtenOutputs              map[string]string
+	writtenSummary              string
 )
 
+// mockProvider is a client.DNSProvider delegating to the mock functions
+type mockProvider struct{}
+
+func (mockProvider) GetZoneIDByName(_ context.Context, zoneName string) (string, error) {
+	return mockGetZoneIDByNameFunc(zoneName)
+}
+
+func (mockProvider) ListRecords(_ context.Context, zoneID, recordName, recordType string) ([]models.RecordData, error) {
+	return mockListRecordsOnZoneFunc(zoneID, recordName, recordType)
+}
+
+func (mockProvider) GetRecords(_ context.Context, zoneID string, record models.Record) ([]models.RecordData, error) {
+	return mockDoesRecordExistOnZoneFunc(zoneID, record)
+}
+
+func (mockProvider) CreateRecord(_ context.Context, zoneID string, record models.Record) (string, error) {
+	return mockCreateRecordOnZoneFunc(zoneID, record)
+}
+
+func (mockProvider) UpdateRecord(_ context.Context, zoneID, recordID string, record models.Record) (bool, error) {
+	return mockUpdateRecordOnZoneFunc(zoneID, recordID, record)
+}
+
+func (mockProvider) DeleteRecord(_ context.Context, zoneID, recordID string, record models.Record) (bool, error) {
+	return mockDeleteRecordOnZoneFunc(zoneID, recordID, record)
+}
+
 // Track if exit was called
 var exitCalled bool
 var exitCode int
@@ -45,23 +81,27 @@ func init() {
 			}
 		}
 	}
-	clientGetZoneIDByName = func(zoneName string) (string, error) { return mockGetZoneIDByNameFunc(zoneName) }
-	clientDoesRecordExistOnZone = func(zoneID, recordName string) (string, error) { return mockDoesRecordExistOnZoneFunc(zoneID, recordName) }
-	clientUpdateRecordOnZone = func(zoneID, recordID string, record models.Record) (bool, error) {
-		return mockUpdateRecordOnZoneFunc(zoneID, recordID, record)
+	utilsWriteGitHubOutputs = func(outputs map[string]string) error {
+		writtenOutputs = outputs
+		return nil
 	}
-	clientCreateRecordOnZone = func(zoneID string, record models.Record) (bool, error) {
-		return mockCreateRecordOnZoneFunc(zoneID, record)
-	}
-	clientDeleteRecordOnZone = func(zoneID, recordID string, record models.Record) (bool, error) {
-		return mockDeleteRecordOnZoneFunc(zoneID, recordID, record)
+	utilsWriteGitHubStepSummary = func(markdown string) error {
+		writtenSummary += markdown
+		return nil
 	}
 }
 
+// newMockProvider returns a mockProvider, in place of the Cloudflare client
+func newMockProvider() (client.DNSProvider, error) {
+	return mockProvider{}, nil
+}
+
 func resetTestState() {
 	exitCalled = false
 	exitCode = 0
 	mockHandleErrorFunc = nil
+	writtenOutputs = nil
+	writtenSummary = ""
 }
 
 func TestUpdateRecord(t *testing.T) {
@@ -81,10 +121,15 @@ func TestUpdateRecord(t *testing.T) {
 	}
 	mockValidateArgsFunc = func(args *models.Args) error { return nil }
 	mockGetZoneIDByNameFunc = func(zoneName string) (string, error) { return "test-zone-id", nil }
-	mockDoesRecordExistOnZoneFunc = func(zoneID, recordName string) (string, error) { return "test-record-id", nil }
+	mockDoesRecordExistOnZoneFunc = func(zoneID string, record models.Record) ([]models.RecordData, error) {
+		return []models.RecordData{{ZoneID: zoneID, RecordID: "test-record-id", Record: record}}, nil
+	}
+	mockListRecordsOnZoneFunc = func(zoneID, recordName, recordType string) ([]models.RecordData, error) {
+		return []models.RecordData{{ZoneID: zoneID, RecordID: "test-record-id", Record: models.Record{Record: recordName, Type: recordType, Target: "192.168.1.100", Proxy: true, Ttl: 3600}}}, nil
+	}
 	mockUpdateRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) { return true, nil }
-	mockCreateRecordOnZoneFunc = func(zoneID string, record models.Record) (bool, error) {
-		return false,
+	mockCreateRecordOnZoneFunc = func(zoneID string, record models.Record) (string, error) {
+		return "",
 		errors.New("should not be called")
 	}
 	mockDeleteRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
@@ -92,7 +137,7 @@ func TestUpdateRecord(t *testing.T) {
 		errors.New("should not be called")
 	}
 
-	result := run()
+	result := run(newMockProvider)
 
 	if exitCalled {
 		t.Errorf("Exit was called unexpectedly")
@@ -119,18 +164,19 @@ func TestCreateRecord(t *testing.T) {
 	}
 	mockValidateArgsFunc = func(args *models.Args) error { return nil }
 	mockGetZoneIDByNameFunc = func(zoneName string) (string, error) { return "test-zone-id", nil }
-	mockDoesRecordExistOnZoneFunc = func(zoneID, recordName string) (string, error) { return "", nil }
+	mockDoesRecordExistOnZoneFunc = func(zoneID string, record models.Record) ([]models.RecordData, error) { return nil, nil }
+	mockListRecordsOnZoneFunc = func(zoneID, recordName, recordType string) ([]models.RecordData, error) { return nil, nil }
 	mockUpdateRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
 		return false,
 		errors.New("should not be called")
 	}
-	mockCreateRecordOnZoneFunc = func(zoneID string, record models.Record) (bool, error) { return true, nil }
+	mockCreateRecordOnZoneFunc = func(zoneID string, record models.Record) (string, error) { return "new-record-id", nil }
 	mockDeleteRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
 		return false,
 		errors.New("should not be called")
 	}
 
-	result := run()
+	result := run(newMockProvider)
 
 	if exitCalled {
 		t.Errorf("Exit was called unexpectedly")
@@ -153,18 +199,20 @@ func TestDeleteRecord(t *testing.T) {
 	}
 	mockValidateArgsFunc = func(args *models.Args) error { return nil }
 	mockGetZoneIDByNameFunc = func(zoneName string) (string, error) { return "test-zone-id", nil }
-	mockDoesRecordExistOnZoneFunc = func(zoneID, recordName string) (string, error) { return "test-record-id", nil }
+	mockDoesRecordExistOnZoneFunc = func(zoneID string, record models.Record) ([]models.RecordData, error) {
+		return []models.RecordData{{ZoneID: zoneID, RecordID: "test-record-id", Record: record}}, nil
+	}
 	mockUpdateRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
 		return false,
 		errors.New("should not be called")
 	}
-	mockCreateRecordOnZoneFunc = func(zoneID string, record models.Record) (bool, error) {
-		return false,
+	mockCreateRecordOnZoneFunc = func(zoneID string, record models.Record) (string, error) {
+		return "",
 		errors.New("should not be called")
 	}
 	mockDeleteRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) { return true, nil }
 
-	result := run()
+	result := run(newMockProvider)
 
 	if exitCalled {
 		t.Errorf("Exit was called unexpectedly")
@@ -193,7 +241,7 @@ func TestGetZoneIDByNameFails(t *testing.T) {
 	mockValidateArgsFunc = func(args *models.Args) error { return nil }
 	mockGetZoneIDByNameFunc = func(zoneName string) (string, error) { return "", errors.New("test error") }
 
-	run()
+	run(newMockProvider)
 }
 
 func TestDoesRecordExistOnZoneFails(t *testing.T) {
@@ -214,9 +262,11 @@ func TestDoesRecordExistOnZoneFails(t *testing.T) {
 	mockParseArgsFunc = func() models.Args { return models.Args{} }
 	mockValidateArgsFunc = func(args *models.Args) error { return nil }
 	mockGetZoneIDByNameFunc = func(zoneName string) (string, error) { return "test-zone-id", nil }
-	mockDoesRecordExistOnZoneFunc = func(zoneID, recordName string) (string, error) { return "", errors.New("test error") }
+	mockDoesRecordExistOnZoneFunc = func(zoneID string, record models.Record) ([]models.RecordData, error) {
+		return nil, errors.New("test error")
+	}
 
-	run()
+	run(newMockProvider)
 }
 
 func TestDeleteNonExistentRecord(t *testing.T) {
@@ -232,13 +282,13 @@ func TestDeleteNonExistentRecord(t *testing.T) {
 	}
 	mockValidateArgsFunc = func(args *models.Args) error { return nil }
 	mockGetZoneIDByNameFunc = func(zoneName string) (string, error) { return "test-zone-id", nil }
-	mockDoesRecordExistOnZoneFunc = func(zoneID, recordName string) (string, error) { return "", nil } // Record doesn't exist
+	mockDoesRecordExistOnZoneFunc = func(zoneID string, record models.Record) ([]models.RecordData, error) { return nil, nil } // Record doesn't exist
 	mockUpdateRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
 		return false,
 		errors.New("should not be called")
 	}
-	mockCreateRecordOnZoneFunc = func(zoneID string, record models.Record) (bool, error) {
-		return false,
+	mockCreateRecordOnZoneFunc = func(zoneID string, record models.Record) (string, error) {
+		return "",
 		errors.New("should not be called")
 	}
 	mockDeleteRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
@@ -246,7 +296,7 @@ func TestDeleteNonExistentRecord(t *testing.T) {
 		errors.New("should not be called")
 	}
 
-	result := run()
+	result := run(newMockProvider)
 
 	if exitCalled {
 		t.Errorf("Exit was not expected to be called")
@@ -256,3 +306,473 @@ func TestDeleteNonExistentRecord(t *testing.T) {
 		t.Errorf("Expected exit code 1, got %d", result)
 	}
 }
+
+func TestReconcileNSRecordSet(t *testing.T) {
+	resetTestState()
+
+	mockLoadEnvFunc = func() error { return nil }
+	mockParseArgsFunc = func() models.Args {
+		return models.Args{
+			Record:   "dev.example.com",
+			ZoneName: "example.com",
+			Target:   "ns1.example.net,ns2.example.net",
+			Type:     "NS",
+			Ttl:      3600,
+		}
+	}
+	mockValidateArgsFunc = func(args *models.Args) error { return nil }
+	mockGetZoneIDByNameFunc = func(zoneName string) (string, error) { return "test-zone-id", nil }
+	mockDoesRecordExistOnZoneFunc = func(zoneID string, record models.Record) ([]models.RecordData, error) {
+		return nil, errors.New("should not be called")
+	}
+	mockListRecordsOnZoneFunc = func(zoneID, recordName, recordType string) ([]models.RecordData, error) {
+		return []models.RecordData{
+			{ZoneID: zoneID, RecordID: "ns1-id", Record: models.Record{Record: recordName, Type: "NS", Target: "ns1.example.net", Ttl: 3600}},
+			{ZoneID: zoneID, RecordID: "old1-id", Record: models.Record{Record: recordName, Type: "NS", Target: "old1.example.net", Ttl: 3600}},
+			{ZoneID: zoneID, RecordID: "stale-id", Record: models.Record{Record: recordName, Type: "NS", Target: "old2.example.net", Ttl: 3600}},
+		}, nil
+	}
+
+	updated := make(map[string]string)
+	var deleted []string
+	mockUpdateRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
+		updated[recordID] = record.Target
+		return true, nil
+	}
+	mockCreateRecordOnZoneFunc = func(zoneID string, record models.Record) (string, error) {
+		return "", errors.New("should not be called")
+	}
+	mockDeleteRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
+		deleted = append(deleted, recordID)
+		return true, nil
+	}
+
+	result := run(newMockProvider)
+
+	if exitCalled {
+		t.Errorf("Exit was called unexpectedly")
+	}
+	if result != 0 {
+		t.Errorf("Expected exit code 0, got %d", result)
+	}
+	if len(updated) != 1 || updated["old1-id"] != "ns2.example.net" {
+		t.Errorf("Expected old1-id to be updated to ns2.example.net, got %v", updated)
+	}
+	if len(deleted) != 1 || deleted[0] != "stale-id" {
+		t.Errorf("Expected stale-id to be deleted, got %v", deleted)
+	}
+}
+
+func TestUpdateRecordOfMatchingType(t *testing.T) {
+	resetTestState()
+
+	mockLoadEnvFunc = func() error { return nil }
+	mockParseArgsFunc = func() models.Args {
+		return models.Args{
+			Record:   "dual.example.com",
+			ZoneName: "example.com",
+			Target:   "2001:db8::2",
+			Type:     "AAAA",
+			Ttl:      3600,
+		}
+	}
+	mockValidateArgsFunc = func(args *models.Args) error { return nil }
+	mockGetZoneIDByNameFunc = func(zoneName string) (string, error) { return "test-zone-id", nil }
+	mockListRecordsOnZoneFunc = func(zoneID, recordName, recordType string) ([]models.RecordData, error) {
+		if recordType != "AAAA" {
+			t.Errorf("Expected lookup by type AAAA, got %s", recordType)
+		}
+		return []models.RecordData{{ZoneID: zoneID, RecordID: "aaaa-record-id", Record: models.Record{Record: recordName, Type: "AAAA", Target: "2001:db8::1"}}}, nil
+	}
+
+	var updatedID string
+	mockUpdateRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
+		updatedID = recordID
+		return true, nil
+	}
+	mockCreateRecordOnZoneFunc = func(zoneID string, record models.Record) (string, error) {
+		return "", errors.New("should not be called")
+	}
+	mockDeleteRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
+		return false, errors.New("should not be called")
+	}
+
+	result := run(newMockProvider)
+
+	if result != 0 {
+		t.Errorf("Expected exit code 0, got %d", result)
+	}
+	if updatedID != "aaaa-record-id" {
+		t.Errorf("Expected aaaa-record-id to be updated, got %q", updatedID)
+	}
+}
+
+func TestUpdateMXRecordOfMatchingType(t *testing.T) {
+	resetTestState()
+
+	mockLoadEnvFunc = func() error { return nil }
+	mockParseArgsFunc = func() models.Args {
+		return models.Args{
+			Record:   "example.com",
+			ZoneName: "example.com",
+			Target:   "mail.example.com",
+			Type:     "MX",
+			Priority: new(int),
+			Ttl:      3600,
+		}
+	}
+	mockValidateArgsFunc = func(args *models.Args) error { return nil }
+	mockGetZoneIDByNameFunc = func(zoneName string) (string, error) { return "test-zone-id", nil }
+	mockDoesRecordExistOnZoneFunc = func(zoneID string, record models.Record) ([]models.RecordData, error) {
+		if record.Type != "MX" {
+			t.Errorf("Expected lookup by type MX, got %s", record.Type)
+		}
+		return []models.RecordData{{ZoneID: zoneID, RecordID: "mx-record-id", Record: models.Record{Record: record.Record, Type: "MX", Target: "mail.example.com"}}}, nil
+	}
+
+	var updatedID string
+	mockUpdateRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
+		updatedID = recordID
+		return true, nil
+	}
+	mockCreateRecordOnZoneFunc = func(zoneID string, record models.Record) (string, error) {
+		return "", errors.New("should not be called")
+	}
+	mockDeleteRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
+		return false, errors.New("should not be called")
+	}
+
+	result := run(newMockProvider)
+
+	if result != 0 {
+		t.Errorf("Expected exit code 0, got %d", result)
+	}
+	if updatedID != "mx-record-id" {
+		t.Errorf("Expected mx-record-id to be updated, got %q", updatedID)
+	}
+}
+
+func TestReconcileRoundRobinRecordSet(t *testing.T) {
+	resetTestState()
+
+	mockLoadEnvFunc = func() error { return nil }
+	mockParseArgsFunc = func() models.Args {
+		return models.Args{
+			Record:   "ingress.example.com",
+			ZoneName: "example.com",
+			Target:   "192.0.2.1,192.0.2.2,192.0.2.3",
+			Type:     "A",
+			Ttl:      300,
+		}
+	}
+	mockValidateArgsFunc = func(args *models.Args) error { return nil }
+	mockGetZoneIDByNameFunc = func(zoneName string) (string, error) { return "test-zone-id", nil }
+	mockListRecordsOnZoneFunc = func(zoneID, recordName, recordType string) ([]models.RecordData, error) {
+		if recordType != "A" {
+			t.Errorf("Expected lookup by type A, got %s", recordType)
+		}
+		return []models.RecordData{
+			{ZoneID: zoneID, RecordID: "keep-id", Record: models.Record{Record: recordName, Type: "A", Target: "192.0.2.1", Ttl: 300}},
+		}, nil
+	}
+
+	var created []string
+	mockCreateRecordOnZoneFunc = func(zoneID string, record models.Record) (string, error) {
+		created = append(created, record.Target)
+		return "new-record-id", nil
+	}
+	mockUpdateRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
+		return false, errors.New("should not be called")
+	}
+	mockDeleteRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
+		return false, errors.New("should not be called")
+	}
+
+	result := run(newMockProvider)
+
+	if result != 0 {
+		t.Errorf("Expected exit code 0, got %d", result)
+	}
+	if len(created) != 2 || created[0] != "192.0.2.2" || created[1] != "192.0.2.3" {
+		t.Errorf("Expected 192.0.2.2 and 192.0.2.3 to be created, got %v", created)
+	}
+}
+
+func TestDeleteAmbiguousRecord(t *testing.T) {
+	resetTestState()
+
+	mockLoadEnvFunc = func() error { return nil }
+	mockParseArgsFunc = func() models.Args {
+		return models.Args{
+			Record:   "dual.example.com",
+			ZoneName: "example.com",
+			Delete:   true,
+		}
+	}
+	mockValidateArgsFunc = func(args *models.Args) error { return nil }
+	mockGetZoneIDByNameFunc = func(zoneName string) (string, error) { return "test-zone-id", nil }
+	mockDoesRecordExistOnZoneFunc = func(zoneID string, record models.Record) ([]models.RecordData, error) {
+		return []models.RecordData{
+			{ZoneID: zoneID, RecordID: "a-record-id", Record: models.Record{Record: record.Record, Type: "A"}},
+			{ZoneID: zoneID, RecordID: "aaaa-record-id", Record: models.Record{Record: record.Record, Type: "AAAA"}},
+		}, nil
+	}
+	mockDeleteRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
+		return false, errors.New("should not be called")
+	}
+
+	result := run(newMockProvider)
+
+	if result != 1 {
+		t.Errorf("Expected exit code 1, got %d", result)
+	}
+}
+
+func TestDryRunDoesNotWrite(t *testing.T) {
+	resetTestState()
+
+	mockLoadEnvFunc = func() error { return nil }
+	mockParseArgsFunc = func() models.Args {
+		return models.Args{
+			Record:   "www.example.com",
+			ZoneName: "example.com",
+			Target:   "192.0.2.2,192.0.2.3",
+			Type:     "A",
+			Ttl:      3600,
+			DryRun:   true,
+		}
+	}
+	mockValidateArgsFunc = func(args *models.Args) error { return nil }
+	mockGetZoneIDByNameFunc = func(zoneName string) (string, error) { return "test-zone-id", nil }
+	mockListRecordsOnZoneFunc = func(zoneID, recordName, recordType string) ([]models.RecordData, error) {
+		return []models.RecordData{
+			{ZoneID: zoneID, RecordID: "old-id", Record: models.Record{Record: recordName, Type: "A", Target: "192.0.2.1", Ttl: 300}},
+		}, nil
+	}
+	writes := 0
+	mockCreateRecordOnZoneFunc = func(zoneID string, record models.Record) (string, error) {
+		writes++
+		return "new-record-id", nil
+	}
+	mockUpdateRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
+		writes++
+		return true, nil
+	}
+	mockDeleteRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
+		writes++
+		return true, nil
+	}
+
+	result := run(newMockProvider)
+
+	if exitCalled {
+		t.Errorf("Exit was called unexpectedly")
+	}
+	if result != 0 {
+		t.Errorf("Expected exit code 0, got %d", result)
+	}
+	if writes != 0 {
+		t.Errorf("Expected no API writes in dry-run mode, got %d", writes)
+	}
+}
+
+func TestSkipUnchangedRecord(t *testing.T) {
+	resetTestState()
+
+	mockLoadEnvFunc = func() error { return nil }
+	mockParseArgsFunc = func() models.Args {
+		return models.Args{
+			Record:   "www.example.com",
+			ZoneName: "example.com",
+			Target:   "example.net",
+			Type:     "CNAME",
+			Proxy:    true,
+			Ttl:      3600,
+		}
+	}
+	mockValidateArgsFunc = func(args *models.Args) error { return nil }
+	mockGetZoneIDByNameFunc = func(zoneName string) (string, error) { return "test-zone-id", nil }
+	mockDoesRecordExistOnZoneFunc = func(zoneID string, record models.Record) ([]models.RecordData, error) {
+		return []models.RecordData{
+			{ZoneID: zoneID, RecordID: "cname-id", Record: models.Record{Record: "www.example.com", Type: "CNAME", Target: "example.net", Proxy: true, Ttl: 1}},
+		}, nil
+	}
+	mockUpdateRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) {
+		return false, errors.New("should not be called")
+	}
+	mockCreateRecordOnZoneFunc = func(zoneID string, record models.Record) (string, error) {
+		return "", errors.New("should not be called")
+	}
+
+	result := run(newMockProvider)
+
+	if exitCalled {
+		t.Errorf("Exit was called unexpectedly")
+	}
+	if result != 0 {
+		t.Errorf("Expected exit code 0, got %d", result)
+	}
+}
+
+func TestStepOutputsAfterUpdate(t *testing.T) {
+	resetTestState()
+
+	mockLoadEnvFunc = func() error { return nil }
+	mockParseArgsFunc = func() models.Args {
+		return models.Args{
+			Record:   "www.example.com",
+			ZoneName: "example.com",
+			Target:   "new.example.net",
+			Type:     "CNAME",
+			Ttl:      3600,
+		}
+	}
+	mockValidateArgsFunc = func(args *models.Args) error { return nil }
+	mockGetZoneIDByNameFunc = func(zoneName string) (string, error) { return "test-zone-id", nil }
+	mockDoesRecordExistOnZoneFunc = func(zoneID string, record models.Record) ([]models.RecordData, error) {
+		return []models.RecordData{
+			{ZoneID: zoneID, RecordID: "cname-id", Record: models.Record{Record: "www.example.com", Type: "CNAME", Target: "old.example.net", Ttl: 3600}},
+		}, nil
+	}
+	mockUpdateRecordOnZoneFunc = func(zoneID, recordID string, record models.Record) (bool, error) { return true, nil }
+
+	result := run(newMockProvider)
+
+	if result != 0 {
+		t.Fatalf("Expected exit code 0, got %d", result)
+	}
+	expected := map[string]string{
+		"record_id":        "cname-id",
+		"zone_id":          "test-zone-id",
+		"operation":        "updated",
+		"previous_content": "old.example.net",
+		"fqdn":             "www.example.com",
+	}
+	for key, value := range expected {
+		if writtenOutputs[key] != value {
+			t.Errorf("Output %s is incorrect, got: %q, want: %q", key, writtenOutputs[key], value)
+		}
+	}
+}
+
+func TestRunTimesOut(t *testing.T) {
+	resetTestState()
+
+	mockLoadEnvFunc = func() error { return nil }
+	mockParseArgsFunc = func() models.Args {
+		return models.Args{
+			Record:   "www.example.com",
+			ZoneName: "example.com",
+			Target:   "192.0.2.1",
+			Type:     "A",
+			Ttl:      3600,
+			Timeout:  time.Nanosecond,
+		}
+	}
+	mockValidateArgsFunc = func(args *models.Args) error { return nil }
+	mockGetZoneIDByNameFunc = func(zoneName string) (string, error) {
+		time.Sleep(time.Millisecond)
+		return "test-zone-id", nil
+	}
+	mockDoesRecordExistOnZoneFunc = func(zoneID string, record models.Record) ([]models.RecordData, error) {
+		t.Errorf("Expected no lookups after the timeout")
+		return nil, nil
+	}
+
+	result := run(newMockProvider)
+
+	if !exitCalled {
+		t.Errorf("Expected exit to be called")
+	}
+	if result != utils.ExitTimeout {
+		t.Errorf("Expected exit code %d, got %d", utils.ExitTimeout, result)
+	}
+}
+
+func TestInvalidCredentials(t *testing.T) {
+	resetTestState()
+
+	mockLoadEnvFunc = func() error { return nil }
+	mockParseArgsFunc = func() models.Args {
+		return models.Args{Record: "www.example.com", ZoneName: "example.com", Target: "192.0.2.1", Type: "A", Ttl: 3600}
+	}
+	mockValidateArgsFunc = func(args *models.Args) error { return nil }
+	newProvider := func() (client.DNSProvider, error) {
+		return nil, fmt.Errorf("%w: CLOUDFLARE_API_KEY requires CLOUDFLARE_API_EMAIL", client.ErrAuth)
+	}
+	mockGetZoneIDByNameFunc = func(zoneName string) (string, error) {
+		t.Errorf("Expected no API calls with invalid credentials")
+		return "", nil
+	}
+
+	result := run(newProvider)
+
+	if !exitCalled {
+		t.Errorf("Expected exit to be called")
+	}
+	if result != utils.ExitAuth {
+		t.Errorf("Expected exit code %d, got %d", utils.ExitAuth, result)
+	}
+}
+
+func TestInvalidArgumentsReportedBeforeCredentials(t *testing.T) {
+	resetTestState()
+
+	mockLoadEnvFunc = func() error { return nil }
+	mockParseArgsFunc = func() models.Args {
+		return models.Args{Record: "www.example.com", ZoneName: "example.com", Type: "A", Ttl: 3600}
+	}
+	mockValidateArgsFunc = func(args *models.Args) error { return errors.New("target is required") }
+	newProvider := func() (client.DNSProvider, error) {
+		t.Errorf("Expected the provider not to be created with invalid arguments")
+		return nil, fmt.Errorf("%w: no credentials found", client.ErrAuth)
+	}
+
+	result := run(newProvider)
+
+	if !exitCalled {
+		t.Errorf("Expected exit to be called")
+	}
+	if result != utils.ExitValidation {
+		t.Errorf("Expected exit code %d, got %d", utils.ExitValidation, result)
+	}
+}
+
+func TestMissingManifestReportedBeforeCredentials(t *testing.T) {
+	resetTestState()
+
+	mockLoadEnvFunc = func() error { return nil }
+	mockParseArgsFunc = func() models.Args {
+		return models.Args{Apply: &models.ApplyArgs{File: filepath.Join(t.TempDir(), "missing.yaml")}}
+	}
+	newProvider := func() (client.DNSProvider, error) {
+		t.Errorf("Expected the provider not to be created without a manifest")
+		return nil, fmt.Errorf("%w: no credentials found", client.ErrAuth)
+	}
+
+	result := run(newProvider)
+
+	if result != utils.ExitValidation {
+		t.Errorf("Expected exit code %d, got %d", utils.ExitValidation, result)
+	}
+}
+
+func TestRunRejectsInvalidRetrySettings(t *testing.T) {
+	resetTestState()
+	t.Cleanup(func() { config.Load() })
+	t.Setenv("RETRY_MAX_ATTEMPTS", "abc")
+
+	mockParseArgsFunc = func() models.Args {
+		t.Errorf("Expected arguments not to be parsed with an invalid configuration")
+		return models.Args{}
+	}
+
+	result := run(newMockProvider)
+
+	if !exitCalled {
+		t.Errorf("Expected exit to be called")
+	}
+	if result != utils.ExitValidation {
+		t.Errorf("Expected exit code %d, got %d", utils.ExitValidation, result)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"yaca/models"
+	"yaca/pkg/apply"
+	"yaca/pkg/logger"
+	"yaca/pkg/utils"
+)
+
+// stepOutputs returns the step outputs describing the changes made to a
+// record or record set, for later steps of the workflow
+func stepOutputs(zoneID, zoneName string, changes []models.Change) map[string]string {
+	outputs := map[string]string{
+		"zone_id":   zoneID,
+		"operation": summarizeOperation(changes),
+	}
+	if len(changes) == 0 {
+		return outputs
+	}
+
+	primary := changes[0]
+	for _, change := range changes {
+		if change.Operation != models.OperationUnchanged {
+			primary = change
+			break
+		}
+	}
+	outputs["record_id"] = primary.RecordID
+	outputs["fqdn"] = fqdn(primary.Name(), zoneName)
+
+	var previous []string
+	for _, change := range changes {
+		if change.Before != nil {
+			previous = append(previous, change.Before.Target)
+		}
+	}
+	outputs["previous_content"] = strings.Join(previous, ",")
+
+	return outputs
+}
+
+// recordOutput describes the outcome of a manifest entry in the records
+// output of apply runs
+type recordOutput struct {
+	Zone            string `json:"zone"`
+	ZoneID          string `json:"zone_id,omitempty"`
+	Name            string `json:"name"`
+	Type            string `json:"type"`
+	FQDN            string `json:"fqdn,omitempty"`
+	Operation       string `json:"operation,omitempty"`
+	RecordID        string `json:"record_id,omitempty"`
+	PreviousContent string `json:"previous_content,omitempty"`
+	ErrorCode       string `json:"error_code,omitempty"`
+}
+
+// applyOutputs returns the step outputs of an apply run: the records output
+// is a JSON list with the outputs of a single-record run for each entry of
+// the manifest, in order
+func applyOutputs(results []apply.Result) (map[string]string, error) {
+	records := []recordOutput{}
+	for _, result := range results {
+		record := recordOutput{
+			Zone:      result.Zone,
+			ZoneID:    result.ZoneID,
+			Name:      result.Record,
+			Type:      result.Type,
+			ErrorCode: utils.ErrorCode(result.Err),
+		}
+		if result.Err == nil {
+			outputs := stepOutputs(result.ZoneID, result.Zone, result.Changes)
+			record.FQDN = outputs["fqdn"]
+			record.Operation = outputs["operation"]
+			record.RecordID = outputs["record_id"]
+			record.PreviousContent = outputs["previous_content"]
+		}
+		records = append(records, record)
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"records": string(data)}, nil
+}
+
+// summarizeOperation reduces the operations applied to a record set to one:
+// a set that was only created, only deleted or left alone reports that, and
+// any other combination is an update of the set
+func summarizeOperation(changes []models.Change) string {
+	operation := models.OperationUnchanged
+	for _, change := range changes {
+		if change.Operation == models.OperationUnchanged {
+			continue
+		}
+		if operation != models.OperationUnchanged && operation != change.Operation {
+			return models.OperationUpdated
+		}
+		operation = change.Operation
+	}
+
+	for _, change := range changes {
+		if change.Operation == models.OperationUnchanged && operation != models.OperationUnchanged {
+			return models.OperationUpdated
+		}
+	}
+	return operation
+}
+
+// fqdn returns the fully qualified name of a record, appending the zone to
+// names given relative to it
+func fqdn(name, zoneName string) string {
+	name = strings.TrimSuffix(name, ".")
+	if utils.IsWithinZone(name, zoneName) {
+		return name
+	}
+	return name + "." + strings.TrimSuffix(zoneName, ".")
+}
+
+// writeErrorCode records the error_code output for failures that are reported
+// without going through the error handler
+func writeErrorCode(err error) {
+	if outputErr := utilsWriteGitHubOutputs(map[string]string{"error_code": utils.ErrorCode(err)}); outputErr != nil {
+		logger.Warn("Failed to write error code output",
+			slog.String("error", outputErr.Error()))
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+	"yaca/client"
+	"yaca/models"
+	"yaca/pkg/apply"
+)
+
+func TestStepOutputs(t *testing.T) {
+	t.Run("should report the created record", func(t *testing.T) {
+		record := models.Record{Record: "api", Type: "CNAME", Target: "example.net"}
+
+		outputs := stepOutputs("zone-id", "example.com", []models.Change{
+			{Operation: models.OperationCreated, RecordID: "new-id", After: &record},
+		})
+
+		if outputs["operation"] != "created" || outputs["record_id"] != "new-id" {
+			t.Errorf("Unexpected outputs: %+v", outputs)
+		}
+		if outputs["fqdn"] != "api.example.com" {
+			t.Errorf("Expected relative name to be qualified, got: %s", outputs["fqdn"])
+		}
+		if outputs["previous_content"] != "" {
+			t.Errorf("Expected no previous content, got: %s", outputs["previous_content"])
+		}
+	})
+
+	t.Run("should summarize a record set", func(t *testing.T) {
+		kept := models.Record{Record: "www.example.com", Type: "A", Target: "192.0.2.1"}
+		stale := models.Record{Record: "www.example.com", Type: "A", Target: "192.0.2.2"}
+
+		outputs := stepOutputs("zone-id", "example.com", []models.Change{
+			{Operation: models.OperationUnchanged, RecordID: "kept-id", Before: &kept, After: &kept},
+			{Operation: models.OperationDeleted, RecordID: "stale-id", Before: &stale},
+		})
+
+		if outputs["operation"] != "updated" {
+			t.Errorf("Expected the set to be reported as updated, got: %s", outputs["operation"])
+		}
+		if outputs["record_id"] != "stale-id" {
+			t.Errorf("Expected the changed record ID, got: %s", outputs["record_id"])
+		}
+		if outputs["previous_content"] != "192.0.2.1,192.0.2.2" {
+			t.Errorf("Unexpected previous content: %s", outputs["previous_content"])
+		}
+	})
+}
+
+func TestApplyOutputs(t *testing.T) {
+	record := models.Record{Record: "www.example.com", Type: "A", Target: "192.0.2.1"}
+
+	outputs, err := applyOutputs([]apply.Result{
+		{Zone: "example.com", ZoneID: "zone-id", Record: "www.example.com", Type: "A", Changes: []models.Change{
+			{Operation: models.OperationCreated, RecordID: "new-id", After: &record},
+		}},
+		{Zone: "example.org", Record: "old.example.org", Type: "CNAME", Err: client.ErrZoneNotFound},
+	})
+
+	if err != nil {
+		t.Fatalf("applyOutputs() returned an error: %v", err)
+	}
+	want := `[{"zone":"example.com","zone_id":"zone-id","name":"www.example.com","type":"A","fqdn":"www.example.com","operation":"created","record_id":"new-id"},` +
+		`{"zone":"example.org","name":"old.example.org","type":"CNAME","error_code":"zone_not_found"}]`
+	if outputs["records"] != want {
+		t.Errorf("Unexpected records output, got: %s, want: %s", outputs["records"], want)
+	}
+}
+
+func TestSummarizeOperation(t *testing.T) {
+	tests := []struct {
+		operations []string
+		expected   string
+	}{
+		{[]string{models.OperationCreated, models.OperationCreated}, models.OperationCreated},
+		{[]string{models.OperationDeleted}, models.OperationDeleted},
+		{[]string{models.OperationUnchanged, models.OperationUnchanged}, models.OperationUnchanged},
+		{[]string{models.OperationCreated, models.OperationDeleted}, models.OperationUpdated},
+		{[]string{models.OperationUnchanged, models.OperationCreated}, models.OperationUpdated},
+		{nil, models.OperationUnchanged},
+	}
+
+	for _, test := range tests {
+		var changes []models.Change
+		for _, operation := range test.operations {
+			changes = append(changes, models.Change{Operation: operation})
+		}
+
+		if got := summarizeOperation(changes); got != test.expected {
+			t.Errorf("summarizeOperation(%v) = %s, want %s", test.operations, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"yaca/models"
+	"yaca/pkg/logger"
+	"yaca/pkg/reconcile"
+)
+
+// planSymbols prefixes each planned change, following the usual diff notation
+var planSymbols = map[string]string{
+	models.OperationCreated: "+",
+	models.OperationUpdated: "~",
+	models.OperationDeleted: "-",
+}
+
+// writePlan prints the planned changes with a field-level before/after diff,
+// followed by a count of changes per operation
+func writePlan(w io.Writer, changes []models.Change) {
+	counts := make(map[string]int)
+	for _, change := range changes {
+		counts[change.Operation]++
+
+		symbol, ok := planSymbols[change.Operation]
+		if !ok {
+			continue
+		}
+
+		fmt.Fprintf(w, "%s %s %s (%s)\n", symbol, change.Operation, logger.MaskValue("record_name", change.Name()), change.Type())
+		for _, field := range reconcile.Fields(change.Before, change.After) {
+			before := maskFieldValue(field.Field, field.Before)
+			after := maskFieldValue(field.Field, field.After)
+
+			switch change.Operation {
+			case models.OperationCreated:
+				fmt.Fprintf(w, "    %s: %s\n", field.Field, after)
+			case models.OperationDeleted:
+				fmt.Fprintf(w, "    %s: %s\n", field.Field, before)
+			default:
+				fmt.Fprintf(w, "    %s: %s -> %s\n", field.Field, before, after)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "Plan: %d to create, %d to update, %d to delete, %d unchanged\n",
+		counts[models.OperationCreated],
+		counts[models.OperationUpdated],
+		counts[models.OperationDeleted],
+		counts[models.OperationUnchanged])
+}
+
+// maskFieldValue applies the logger masking rules to record contents, which
+// the logs mask as targets whatever the record type. It is shared by the plan
+// and the job summary.
+func maskFieldValue(field, value string) string {
+	if field == "content" && value != "" {
+		return logger.MaskValue("target_ip", value)
+	}
+	return value
+}
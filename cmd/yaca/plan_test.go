@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"yaca/models"
+)
+
+func TestWritePlan(t *testing.T) {
+	t.Run("should print a field-level diff", func(t *testing.T) {
+		t.Setenv("DISABLE_LOG_MASKING", "true")
+
+		before := models.Record{Record: "www.example.com", Type: "CNAME", Target: "old.example.net", Ttl: 300}
+		after := models.Record{Record: "www.example.com", Type: "CNAME", Target: "new.example.net", Ttl: 300, Proxy: true}
+		created := models.Record{Record: "api.example.com", Type: "CNAME", Target: "api.example.net", Ttl: 3600}
+
+		var out bytes.Buffer
+		writePlan(&out, []models.Change{
+			{Operation: models.OperationUpdated, RecordID: "id-1", Before: &before, After: &after},
+			{Operation: models.OperationCreated, After: &created},
+			{Operation: models.OperationDeleted, RecordID: "id-2", Before: &before},
+			{Operation: models.OperationUnchanged, RecordID: "id-3", Before: &created, After: &created},
+		})
+
+		plan := out.String()
+		for _, want := range []string{
+			"~ updated www.example.com (CNAME)",
+			"content: old.example.net -> new.example.net",
+			"proxied: false -> true",
+			"+ created api.example.com (CNAME)",
+			"content: api.example.net",
+			"- deleted www.example.com (CNAME)",
+			"Plan: 1 to create, 1 to update, 1 to delete, 1 unchanged",
+		} {
+			if !strings.Contains(plan, want) {
+				t.Errorf("Expected plan to contain %q, got:\n%s", want, plan)
+			}
+		}
+		if strings.Contains(plan, "ttl: 300 -> 300") {
+			t.Errorf("Unchanged fields should not be printed, got:\n%s", plan)
+		}
+	})
+
+	t.Run("should mask names and addresses", func(t *testing.T) {
+		t.Setenv("DISABLE_LOG_MASKING", "")
+
+		record := models.Record{Record: "www.example.com", Type: "A", Target: "192.0.2.1", Ttl: 3600}
+
+		var out bytes.Buffer
+		writePlan(&out, []models.Change{{Operation: models.OperationCreated, After: &record}})
+
+		plan := out.String()
+		if strings.Contains(plan, "www.example.com") || strings.Contains(plan, "192.0.2.1") {
+			t.Errorf("Expected name and address to be masked, got:\n%s", plan)
+		}
+	})
+
+	t.Run("should mask the content of every record type", func(t *testing.T) {
+		t.Setenv("DISABLE_LOG_MASKING", "")
+
+		txt := models.Record{Record: "example.com", Type: "TXT", Target: "google-site-verification=secret-token", Ttl: 3600}
+		cname := models.Record{Record: "www.example.com", Type: "CNAME", Target: "internal.example.net", Ttl: 3600}
+
+		var out bytes.Buffer
+		writePlan(&out, []models.Change{
+			{Operation: models.OperationCreated, After: &txt},
+			{Operation: models.OperationCreated, After: &cname},
+		})
+
+		plan := out.String()
+		if strings.Contains(plan, "secret-token") || strings.Contains(plan, "internal.example.net") {
+			t.Errorf("Expected contents to be masked, got:\n%s", plan)
+		}
+	})
+}
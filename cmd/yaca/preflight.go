@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"yaca/client"
+	"yaca/pkg/logger"
+	"yaca/pkg/utils"
+)
+
+// tokenVerifier is implemented by providers whose credentials can be checked
+// by the verify-token command
+type tokenVerifier interface {
+	AuthMode() client.AuthMode
+	VerifyToken(ctx context.Context) (client.TokenStatus, error)
+	CheckZoneAccess(ctx context.Context, zoneName string, write bool) (client.ZoneAccess, error)
+}
+
+// preflight checks that the credentials can manage the zones before any
+// change is made, for providers that support it, and returns the IDs of the
+// zones it resolved
+func preflight(ctx context.Context, provider client.DNSProvider, zoneNames []string, write bool) (map[string]string, error) {
+	checker, ok := provider.(client.Preflighter)
+	if !ok {
+		return nil, nil
+	}
+	return checker.Preflight(ctx, zoneNames, write)
+}
+
+// runVerifyToken checks the credentials and, if a zone is given, access to
+// its DNS records, printing what was verified
+func runVerifyToken(ctx context.Context, provider client.DNSProvider, zoneName string) int {
+	verifier, ok := provider.(tokenVerifier)
+	if !ok {
+		err := fmt.Errorf("%w: the DNS provider does not support credential checks", utils.ErrInvalidArguments)
+		utilsHandleError(err, "Failed to verify credentials")
+		return utils.ExitCode(err)
+	}
+
+	err := verifyCredentials(ctx, os.Stdout, verifier, zoneName)
+	utilsHandleError(err, "Failed to verify credentials")
+	return utils.ExitCode(err)
+}
+
+// verifyCredentials writes the state of the token and of the access to the
+// zone, stopping at the first check that fails
+func verifyCredentials(ctx context.Context, w io.Writer, verifier tokenVerifier, zoneName string) error {
+	if verifier.AuthMode() == client.AuthModeToken {
+		status, err := verifier.VerifyToken(ctx)
+		switch {
+		case err != nil && zoneName == "":
+			fmt.Fprintf(w, "Token: %v\n", err)
+			return err
+		case err != nil:
+			// Account-owned tokens are rejected by the user verification
+			// endpoint, so the zone access decides
+			fmt.Fprintf(w, "Token: %v, checking zone access instead\n", err)
+		default:
+			expiry := "no expiry"
+			if !status.ExpiresOn.IsZero() {
+				expiry = "expires " + status.ExpiresOn.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "Token: %s, %s\n", status.Status, expiry)
+		}
+	} else {
+		fmt.Fprintf(w, "Authentication: %s, token verification does not apply\n", verifier.AuthMode())
+	}
+
+	if zoneName == "" {
+		return nil
+	}
+
+	maskedZone := logger.MaskValue("zone_name", zoneName)
+	access, err := verifier.CheckZoneAccess(ctx, zoneName, true)
+	if err != nil {
+		fmt.Fprintf(w, "Zone %s: %v\n", maskedZone, err)
+		return err
+	}
+
+	edit := "ok"
+	if !access.EditChecked {
+		edit = "not reported by the API"
+	}
+	fmt.Fprintf(w, "Zone %s: Zone Read ok, DNS Read ok, DNS Edit %s\n", maskedZone, edit)
+	return nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"yaca/client"
+	"yaca/models"
+	"yaca/pkg/cloudflaretest"
+	"yaca/pkg/utils"
+)
+
+func TestRunStopsWithoutDNSEditPermission(t *testing.T) {
+	resetTestState()
+
+	server := cloudflaretest.NewServer()
+	defer server.Close()
+	zoneID := server.AddZone("example.com")
+	server.SetZonePermissions(zoneID, "#zone:read", "#dns_records:read")
+
+	mockLoadEnvFunc = func() error { return nil }
+	mockParseArgsFunc = func() models.Args {
+		return models.Args{Record: "www.example.com", ZoneName: "example.com", Target: "192.0.2.1", Type: "A", Ttl: 3600}
+	}
+	mockValidateArgsFunc = func(args *models.Args) error { return nil }
+	newProvider := func() (client.DNSProvider, error) { return client.NewCloudflare(server.Client()), nil }
+
+	result := run(newProvider)
+
+	if result != utils.ExitAuth {
+		t.Errorf("Expected exit code %d, got %d", utils.ExitAuth, result)
+	}
+	for _, request := range server.Requests() {
+		if request.Method != http.MethodGet {
+			t.Errorf("Expected no writes, got %s %s", request.Method, request.Path)
+		}
+	}
+	if len(server.Records(zoneID)) != 0 {
+		t.Errorf("Expected the zone to be left untouched")
+	}
+}
+
+func TestRunReusesZoneFromPreflight(t *testing.T) {
+	resetTestState()
+
+	server := cloudflaretest.NewServer()
+	defer server.Close()
+	zoneID := server.AddZone("example.com")
+
+	mockLoadEnvFunc = func() error { return nil }
+	mockParseArgsFunc = func() models.Args {
+		return models.Args{Record: "www.example.com", ZoneName: "example.com", Target: "192.0.2.1", Type: "A", Ttl: 3600}
+	}
+	mockValidateArgsFunc = func(args *models.Args) error { return nil }
+	newProvider := func() (client.DNSProvider, error) { return client.NewCloudflare(server.Client()), nil }
+
+	result := run(newProvider)
+
+	if result != 0 {
+		t.Errorf("Expected exit code 0, got %d", result)
+	}
+	zoneLookups := 0
+	for _, request := range server.Requests() {
+		if request.Path == "/zones" {
+			zoneLookups++
+		}
+	}
+	if zoneLookups != 1 {
+		t.Errorf("Expected the zone to be looked up once, got %d lookups", zoneLookups)
+	}
+	if len(server.Records(zoneID)) != 1 {
+		t.Errorf("Expected the record to be created")
+	}
+}
+
+func TestRunSkipsPreflight(t *testing.T) {
+	resetTestState()
+
+	server := cloudflaretest.NewServer()
+	defer server.Close()
+	zoneID := server.AddZone("example.com")
+	server.SetZonePermissions(zoneID, "#zone:read", "#dns_records:read")
+
+	mockLoadEnvFunc = func() error { return nil }
+	mockParseArgsFunc = func() models.Args {
+		return models.Args{Record: "www.example.com", ZoneName: "example.com", Target: "192.0.2.1", Type: "A", Ttl: 3600, SkipPreflight: true}
+	}
+	mockValidateArgsFunc = func(args *models.Args) error { return nil }
+	newProvider := func() (client.DNSProvider, error) { return client.NewCloudflare(server.Client()), nil }
+
+	result := run(newProvider)
+
+	if result != 0 {
+		t.Errorf("Expected exit code 0, got %d", result)
+	}
+	for _, request := range server.Requests() {
+		if request.Path == "/user/tokens/verify" {
+			t.Errorf("Expected the token not to be verified")
+		}
+	}
+	if len(server.Records(zoneID)) != 1 {
+		t.Errorf("Expected the record to be created")
+	}
+}
+
+func TestVerifyCredentials(t *testing.T) {
+	server := cloudflaretest.NewServer()
+	defer server.Close()
+	zoneID := server.AddZone("example.com")
+
+	t.Run("should report the token and zone access", func(t *testing.T) {
+		var out bytes.Buffer
+		err := verifyCredentials(context.Background(), &out, client.NewCloudflare(server.Client()), "example.com")
+
+		if err != nil {
+			t.Fatalf("verifyCredentials() returned an error: %v", err)
+		}
+		if !strings.Contains(out.String(), "Token: active, no expiry") {
+			t.Errorf("Expected the token status, got: %s", out.String())
+		}
+		if !strings.Contains(out.String(), "DNS Edit not reported by the API") {
+			t.Errorf("Expected DNS Edit to be reported as unchecked, got: %s", out.String())
+		}
+	})
+
+	t.Run("should report a missing permission", func(t *testing.T) {
+		server.SetZonePermissions(zoneID, "#zone:read", "#dns_records:read")
+
+		var out bytes.Buffer
+		err := verifyCredentials(context.Background(), &out, client.NewCloudflare(server.Client()), "example.com")
+
+		if !errors.Is(err, client.ErrAuth) {
+			t.Errorf("Expected an authentication error, got: %v", err)
+		}
+		if !strings.Contains(out.String(), "missing the DNS Edit permission") {
+			t.Errorf("Expected the missing permission to be named, got: %s", out.String())
+		}
+	})
+
+	t.Run("should check the zone when the token cannot be verified", func(t *testing.T) {
+		server := cloudflaretest.NewServer()
+		defer server.Close()
+		server.AddZone("example.com")
+		server.Fail(cloudflaretest.Fault{Path: "/user/tokens/verify", Status: 401, Code: 1000, Message: "Invalid API Token"})
+
+		var out bytes.Buffer
+		err := verifyCredentials(context.Background(), &out, client.NewCloudflare(server.Client()), "example.com")
+
+		if err != nil {
+			t.Fatalf("verifyCredentials() returned an error: %v", err)
+		}
+		if !strings.Contains(out.String(), "checking zone access instead") || !strings.Contains(out.String(), "DNS Read ok") {
+			t.Errorf("Expected the zone access to be checked, got: %s", out.String())
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"yaca/models"
+	"yaca/pkg/apply"
+	"yaca/pkg/logger"
+	"yaca/pkg/utils"
+)
+
+// stepSummary renders the changes as a Markdown table for the workflow run
+// page. Names and contents are masked following the logger rules, and failed
+// entries show their error code.
+func stepSummary(results []apply.Result, dryRun bool) string {
+	var summary strings.Builder
+
+	title := "DNS changes"
+	if dryRun {
+		title += " (dry run)"
+	}
+	fmt.Fprintf(&summary, "### %s\n\n", title)
+	summary.WriteString("| Name | Type | Content | TTL | Proxied | Result |\n")
+	summary.WriteString("|------|------|---------|-----|---------|--------|\n")
+
+	for _, result := range results {
+		if result.Err != nil {
+			// Error messages can name zones and records, so only the
+			// class of the error is shown
+			fmt.Fprintf(&summary, "| %s | %s | | | | failed: %s |\n",
+				markdownCell(logger.MaskValue("record_name", result.Record)),
+				markdownCell(result.Type),
+				utils.ErrorCode(result.Err))
+			continue
+		}
+
+		for _, change := range result.Changes {
+			fmt.Fprintf(&summary, "| %s | %s | %s | %s | %s | %s |\n",
+				markdownCell(logger.MaskValue("record_name", change.Name())),
+				markdownCell(change.Type()),
+				markdownCell(summaryValue(change, func(r models.Record) string {
+					return maskFieldValue("content", r.Content())
+				})),
+				summaryValue(change, func(r models.Record) string {
+					return strconv.FormatFloat(r.Ttl, 'f', -1, 64)
+				}),
+				summaryValue(change, func(r models.Record) string {
+					return strconv.FormatBool(r.Proxy)
+				}),
+				change.Operation)
+		}
+	}
+
+	summary.WriteString("\n")
+	return summary.String()
+}
+
+// summaryValue shows a field of the changed record, as "old → new" when the
+// change modified it
+func summaryValue(change models.Change, field func(models.Record) string) string {
+	switch {
+	case change.Before == nil && change.After == nil:
+		return ""
+	case change.Before == nil:
+		return field(*change.After)
+	case change.After == nil:
+		return field(*change.Before)
+	}
+
+	before, after := field(*change.Before), field(*change.After)
+	if before == after {
+		return after
+	}
+	return before + " → " + after
+}
+
+// markdownCell escapes a value so it stays within a single table cell
+func markdownCell(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	return strings.Join(strings.Fields(value), " ")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"yaca/client"
+	"yaca/models"
+	"yaca/pkg/apply"
+	"yaca/pkg/utils"
+)
+
+func TestStepSummary(t *testing.T) {
+	t.Run("should render a table of the changes", func(t *testing.T) {
+		t.Setenv("DISABLE_LOG_MASKING", "true")
+
+		before := models.Record{Record: "www.example.com", Type: "CNAME", Target: "old.example.net", Ttl: 300}
+		after := models.Record{Record: "www.example.com", Type: "CNAME", Target: "new.example.net", Ttl: 300, Proxy: true}
+
+		summary := stepSummary([]apply.Result{
+			{Record: "www.example.com", Type: "CNAME", Changes: []models.Change{
+				{Operation: models.OperationUpdated, RecordID: "id-1", Before: &before, After: &after},
+			}},
+			{Record: "mail.example.com", Type: "MX", Err: fmt.Errorf("%w: invalid | value", utils.ErrInvalidArguments)},
+		}, false)
+
+		for _, want := range []string{
+			"### DNS changes\n",
+			"| Name | Type | Content | TTL | Proxied | Result |",
+			"| www.example.com | CNAME | old.example.net → new.example.net | 300 | false → true | updated |",
+			"| mail.example.com | MX | | | | failed: validation |",
+		} {
+			if !strings.Contains(summary, want) {
+				t.Errorf("Expected summary to contain %q, got:\n%s", want, summary)
+			}
+		}
+	})
+
+	t.Run("should mask names and addresses", func(t *testing.T) {
+		t.Setenv("DISABLE_LOG_MASKING", "")
+
+		record := models.Record{Record: "www.example.com", Type: "A", Target: "192.0.2.1", Ttl: 3600}
+		summary := stepSummary([]apply.Result{
+			{Record: "www.example.com", Type: "A", Changes: []models.Change{{Operation: models.OperationCreated, After: &record}}},
+		}, true)
+
+		if strings.Contains(summary, "www.example.com") || strings.Contains(summary, "192.0.2.1") {
+			t.Errorf("Expected name and address to be masked, got:\n%s", summary)
+		}
+		if !strings.Contains(summary, "(dry run)") {
+			t.Errorf("Expected dry run to be noted in the title, got:\n%s", summary)
+		}
+	})
+
+	t.Run("should not leak names or contents of failed and non-address records", func(t *testing.T) {
+		t.Setenv("DISABLE_LOG_MASKING", "")
+
+		record := models.Record{Record: "example.com", Type: "TXT", Target: "google-site-verification=secret-token", Ttl: 3600}
+		summary := stepSummary([]apply.Result{
+			{Record: "example.com", Type: "TXT", Changes: []models.Change{{Operation: models.OperationCreated, After: &record}}},
+			{Zone: "secret-zone.com", Record: "www.secret-zone.com", Type: "CNAME", Err: fmt.Errorf("%w: no zone found with name: secret-zone.com", client.ErrZoneNotFound)},
+		}, false)
+
+		for _, leaked := range []string{"secret-token", "secret-zone"} {
+			if strings.Contains(summary, leaked) {
+				t.Errorf("Expected %q to be masked, got:\n%s", leaked, summary)
+			}
+		}
+		if !strings.Contains(summary, "failed: zone_not_found") {
+			t.Errorf("Expected the error code of the failed entry, got:\n%s", summary)
+		}
+	})
+}
@@ -6,6 +6,7 @@ require (
 	github.com/alexflint/go-arg v1.5.1
 	github.com/cloudflare/cloudflare-go/v4 v4.5.1
 	github.com/joho/godotenv v1.5.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,19 @@
+package models
+
+// Manifest is a declarative list of records grouped by zone
+type Manifest struct {
+	Zones []ManifestZone `json:"zones" yaml:"zones"`
+}
+
+// ManifestZone holds the records managed in a single zone
+type ManifestZone struct {
+	Name    string           `json:"name" yaml:"name"`
+	Records []ManifestRecord `json:"records" yaml:"records"`
+}
+
+// ManifestRecord is a record entry of a manifest. Records marked with delete
+// are removed from the zone instead of being created or updated.
+type ManifestRecord struct {
+	Record `yaml:",inline"`
+	Delete bool `json:"delete,omitempty" yaml:"delete,omitempty"`
+}
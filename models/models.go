@@ -1,21 +1,155 @@
 package models
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 type Args struct {
-	Delete	 bool    `arg:"-d,--delete" name:"Delete" help: "Whether to delete the record name" default:"false"`
-	Record   string  `arg:"required,-r,--record" name:"Record" help: "Record name to be created/updated"`
-	Proxy    bool    `arg:"-p,--proxy" name:"Proxy" help: "Whether to enable Cloudflare proxy for the record name" default:"false"`
-	Target   string  `arg:"-t,--target" name:"Target" help: "Target/IP address the record name should point to"`
-	Ttl      float64 `arg:"-l,--ttl" name:"TTL" help: "Time-to-live for the record name" default:"3600"`
-	Type     string  `arg:"-y,--type" name:"Type" help: "Type of the record name to be created/updated"`
-	ZoneName string  `arg:"required,-z,--zone-name" name:"ZoneName" help: "Zone name of the record name"`
+	Apply         *ApplyArgs `arg:"subcommand:apply" help:"Apply a manifest of records grouped by zone"`
+	CAAFlags      *int       `arg:"--caa-flags" name:"CAAFlags" help:"Flags of the CAA record, 0-255 (CAA records only)"`
+	CAATag        string     `arg:"--caa-tag" name:"CAATag" help:"Property tag of the CAA record: issue, issuewild or iodef (CAA records only)"`
+	Delete        bool       `arg:"-d,--delete" name:"Delete" help:"Whether to delete the record name" default:"false"`
+	DryRun        bool       `arg:"--dry-run" name:"DryRun" help:"Print the changes that would be made without applying them"`
+	Plan          *PlanArgs  `arg:"subcommand:plan" help:"Print the changes that would be made for a record or a manifest without applying them"`
+	Port          *int       `arg:"--port" name:"Port" help:"Port of the service (SRV records only)"`
+	Priority      *int       `arg:"--priority" name:"Priority" help:"Priority of the record (required for MX and SRV records)"`
+	Proto         string     `arg:"--proto" name:"Proto" help:"Protocol of the service, e.g. tcp or udp (SRV records only)"`
+	Record        string     `arg:"-r,--record" name:"Record" help:"Record name to be created/updated"`
+	Proxy         bool       `arg:"-p,--proxy" name:"Proxy" help:"Whether to enable Cloudflare proxy for the record name" default:"false"`
+	Service       string     `arg:"--service" name:"Service" help:"Symbolic name of the service, e.g. sip (SRV records only)"`
+	SkipPreflight bool       `arg:"--skip-preflight" name:"SkipPreflight" help:"Skip the check of the credentials and zone permissions made before any change"`
+	// Target holds the --target values folded together by ParseArgs
+	Target      string           `arg:"-"`
+	Targets     []string         `arg:"-t,--target,separate" name:"Target" help:"Target/IP address the record name should point to; repeat or comma-separate for A, AAAA and NS record sets"`
+	Timeout     time.Duration    `arg:"--timeout" name:"Timeout" help:"Maximum time for the whole operation, e.g. 90s or 5m; 0 disables it" default:"5m"`
+	Ttl         float64          `arg:"-l,--ttl" name:"TTL" help:"Time-to-live for the record name" default:"3600"`
+	Type        string           `arg:"-y,--type" name:"Type" help:"Type of the record name to be created/updated"`
+	VerifyToken *VerifyTokenArgs `arg:"subcommand:verify-token" help:"Check that the credentials are valid and, with --zone-name, that they can read and edit the zone's DNS records"`
+	Weight      *int             `arg:"--weight" name:"Weight" help:"Relative weight for records with the same priority (SRV records only)"`
+	ZoneName    string           `arg:"-z,--zone-name" name:"ZoneName" help:"Zone name of the record name"`
+}
+
+// ApplyArgs holds the arguments of the apply subcommand
+type ApplyArgs struct {
+	File string `arg:"required,-f,--file" name:"File" help:"Path to the YAML or JSON manifest of records"`
+}
+
+// PlanArgs holds the arguments of the plan subcommand
+type PlanArgs struct {
+	File string `arg:"-f,--file" name:"File" help:"Path to a manifest to plan instead of a single record"`
 }
 
+// VerifyTokenArgs holds the arguments of the verify-token subcommand
+type VerifyTokenArgs struct{}
+
 type Record struct {
-	Record string  `json:"name"`
-	Proxy  bool    `json:"proxied"`
-	Target string  `json:"content"`
-	Ttl    float64 `json:"ttl"`
-	Type   string  `json:"type"`
+	CAA      *CAAData `json:"caa,omitempty" yaml:"caa,omitempty"`
+	Priority *int     `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Record   string   `json:"name" yaml:"name"`
+	Proxy    bool     `json:"proxied" yaml:"proxied"`
+	SRV      *SRVData `json:"srv,omitempty" yaml:"srv,omitempty"`
+	Target   string   `json:"content" yaml:"content"`
+	Ttl      float64  `json:"ttl" yaml:"ttl"`
+	Type     string   `json:"type" yaml:"type"`
+}
+
+// SRVData holds the structured fields of an SRV record
+type SRVData struct {
+	Service string `json:"service" yaml:"service"`
+	Proto   string `json:"proto" yaml:"proto"`
+	Weight  int    `json:"weight" yaml:"weight"`
+	Port    int    `json:"port" yaml:"port"`
+}
+
+// RecordName returns the owner name of the SRV record, e.g. _sip._tcp.example.com
+func (d SRVData) RecordName(name string) string {
+	service := strings.TrimPrefix(d.Service, "_")
+	proto := strings.TrimPrefix(d.Proto, "_")
+	return "_" + service + "._" + strings.ToLower(proto) + "." + name
+}
+
+// CAAData holds the structured fields of a CAA record
+type CAAData struct {
+	Flags int    `json:"flags" yaml:"flags"`
+	Tag   string `json:"tag" yaml:"tag"`
+	Value string `json:"value" yaml:"value"`
+}
+
+// Content returns the record content in the format expected by Cloudflare
+func (r Record) Content() string {
+	switch r.Type {
+	case "TXT":
+		return FormatTXTContent(r.Target)
+	case "CAA":
+		if r.CAA == nil {
+			return r.Target
+		}
+		return fmt.Sprintf("%d %s \"%s\"", r.CAA.Flags, r.CAA.Tag, escapeTXTChunk(r.CAA.Value))
+	case "HTTPS", "SVCB":
+		data, err := ParseSVCB(r.Target)
+		if err != nil {
+			return r.Target
+		}
+		return data.String()
+	case "SRV":
+		if r.SRV == nil {
+			return r.Target
+		}
+		return fmt.Sprintf("%d %d %s", r.SRV.Weight, r.SRV.Port, r.Target)
+	default:
+		return r.Target
+	}
+}
+
+// ParseCAAContent parses the content of a CAA record, e.g.
+// 0 issue "letsencrypt.org", reporting false if it is not in that format
+func ParseCAAContent(content string) (CAAData, bool) {
+	fields := strings.SplitN(strings.TrimSpace(content), " ", 3)
+	if len(fields) != 3 {
+		return CAAData{}, false
+	}
+
+	flags, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return CAAData{}, false
+	}
+	strs, ok := parseQuotedTXT(fields[2])
+	if !ok {
+		return CAAData{}, false
+	}
+	return CAAData{Flags: flags, Tag: strings.ToLower(fields[1]), Value: strings.Join(strs, "")}, true
+}
+
+// recordSetTypes lists the record types whose targets are managed as a set,
+// so that every record sharing the name is reconciled against the targets
+var recordSetTypes = map[string]bool{
+	"A":    true,
+	"AAAA": true,
+	"NS":   true,
+}
+
+// IsRecordSetType reports whether records of the given type are reconciled
+// as a set of targets
+func IsRecordSetType(recordType string) bool {
+	return recordSetTypes[recordType]
+}
+
+// multiValueTypes lists the record types for which several records with
+// different content commonly share one name
+var multiValueTypes = map[string]bool{
+	"CAA": true,
+	"MX":  true,
+	"SRV": true,
+	"TXT": true,
+}
+
+// IsMultiValueType reports whether records of the given type are matched on
+// content as well as on name and type
+func IsMultiValueType(recordType string) bool {
+	return multiValueTypes[recordType]
 }
 
 type RecordData struct {
@@ -23,3 +157,59 @@ type RecordData struct {
 	RecordID string
 	Record   Record
 }
+
+// Operations reported for a record once it has been applied
+const (
+	OperationCreated   = "created"
+	OperationUpdated   = "updated"
+	OperationDeleted   = "deleted"
+	OperationUnchanged = "unchanged"
+)
+
+// operationVerbs maps each operation to the verb naming it in messages
+var operationVerbs = map[string]string{
+	OperationCreated:   "create",
+	OperationUpdated:   "update",
+	OperationDeleted:   "delete",
+	OperationUnchanged: "keep",
+}
+
+// OperationVerb returns the verb naming an operation, e.g. "create" for
+// OperationCreated
+func OperationVerb(operation string) string {
+	if verb, ok := operationVerbs[operation]; ok {
+		return verb
+	}
+	return operation
+}
+
+// Change describes an operation applied to a single DNS record. Before is nil
+// for created records and After is nil for deleted ones.
+type Change struct {
+	Operation string
+	RecordID  string
+	Before    *Record
+	After     *Record
+}
+
+// Name returns the name of the record affected by the change
+func (c Change) Name() string {
+	if c.After != nil {
+		return c.After.Record
+	}
+	if c.Before != nil {
+		return c.Before.Record
+	}
+	return ""
+}
+
+// Type returns the type of the record affected by the change
+func (c Change) Type() string {
+	if c.After != nil {
+		return c.After.Type
+	}
+	if c.Before != nil {
+		return c.Before.Type
+	}
+	return ""
+}
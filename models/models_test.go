@@ -0,0 +1,69 @@
+package models
+
+import "testing"
+
+func TestSRVDataRecordName(t *testing.T) {
+	data := SRVData{Service: "_sip", Proto: "TCP"}
+	got := data.RecordName("example.com")
+	want := "_sip._tcp.example.com"
+	if got != want {
+		t.Errorf("RecordName() = %s, want %s", got, want)
+	}
+}
+
+func TestRecordContent(t *testing.T) {
+	t.Run("should return target for plain records", func(t *testing.T) {
+		record := Record{Type: "A", Target: "192.168.1.1"}
+		if got := record.Content(); got != "192.168.1.1" {
+			t.Errorf("Content() = %s, want %s", got, "192.168.1.1")
+		}
+	})
+
+	t.Run("should format SRV content", func(t *testing.T) {
+		record := Record{Type: "SRV", Target: "sip.example.com", SRV: &SRVData{Weight: 5, Port: 5060}}
+		if got := record.Content(); got != "5 5060 sip.example.com" {
+			t.Errorf("Content() = %s, want %s", got, "5 5060 sip.example.com")
+		}
+	})
+	t.Run("should format CAA content", func(t *testing.T) {
+		record := Record{Type: "CAA", CAA: &CAAData{Flags: 0, Tag: "issue", Value: "letsencrypt.org"}}
+		if got := record.Content(); got != `0 issue "letsencrypt.org"` {
+			t.Errorf("Content() = %s, want %s", got, `0 issue "letsencrypt.org"`)
+		}
+	})
+	t.Run("should quote CAA values in presentation format", func(t *testing.T) {
+		record := Record{Type: "CAA", CAA: &CAAData{Tag: "iodef", Value: `mailto:dns@exämple.com?x="y"`}}
+		want := `0 iodef "mailto:dns@exämple.com?x=\"y\""`
+		if got := record.Content(); got != want {
+			t.Errorf("Content() = %s, want %s", got, want)
+		}
+	})
+}
+
+func TestParseCAAContent(t *testing.T) {
+	data, ok := ParseCAAContent(`128 ISSUE "letsencrypt.org; validationmethods=\"dns-01\""`)
+	want := CAAData{Flags: 128, Tag: "issue", Value: `letsencrypt.org; validationmethods="dns-01"`}
+	if !ok || data != want {
+		t.Errorf("ParseCAAContent() = %+v, %v, want %+v", data, ok, want)
+	}
+
+	for _, content := range []string{"", "0 issue", `x issue "letsencrypt.org"`, "0 issue letsencrypt.org"} {
+		if _, ok := ParseCAAContent(content); ok {
+			t.Errorf("ParseCAAContent(%q) should fail", content)
+		}
+	}
+}
+
+func TestOperationVerb(t *testing.T) {
+	verbs := map[string]string{
+		OperationCreated:   "create",
+		OperationUpdated:   "update",
+		OperationDeleted:   "delete",
+		OperationUnchanged: "keep",
+	}
+	for operation, want := range verbs {
+		if got := OperationVerb(operation); got != want {
+			t.Errorf("OperationVerb(%q) = %s, want %s", operation, got, want)
+		}
+	}
+}
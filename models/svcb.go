@@ -0,0 +1,192 @@
+package models
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// SVCBData holds the structured fields of an HTTPS or SVCB record
+type SVCBData struct {
+	Priority int    `json:"priority"`
+	Target   string `json:"target"`
+	Value    string `json:"value"`
+}
+
+// String returns the record in presentation format
+func (d SVCBData) String() string {
+	return strings.TrimSpace(fmt.Sprintf("%d %s %s", d.Priority, d.Target, d.Value))
+}
+
+// svcParamKeys lists the SvcParamKeys defined by RFC 9460 and its extensions
+var svcParamKeys = map[string]bool{
+	"mandatory":       true,
+	"alpn":            true,
+	"no-default-alpn": true,
+	"port":            true,
+	"ipv4hint":        true,
+	"ech":             true,
+	"ipv6hint":        true,
+	"dohpath":         true,
+	"ohttp":           true,
+}
+
+// ParseSVCB parses an HTTPS or SVCB record in presentation format, e.g.
+// `1 . alpn="h3,h2" ipv4hint=192.0.2.1`, and validates its SvcParams
+func ParseSVCB(content string) (*SVCBData, error) {
+	fields, err := splitSVCBFields(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("record must contain at least a priority and a target")
+	}
+
+	priority, err := strconv.ParseUint(fields[0], 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid priority %q: must be between 0 and 65535", fields[0])
+	}
+
+	target := fields[1]
+	if target != "." && strings.ContainsAny(target, `"=`) {
+		return nil, fmt.Errorf("invalid target %q", target)
+	}
+
+	params := fields[2:]
+	if priority == 0 && len(params) > 0 {
+		return nil, fmt.Errorf("alias mode records (priority 0) must not have SvcParams")
+	}
+
+	seen := make(map[string]bool)
+	normalized := make([]string, 0, len(params))
+	for _, param := range params {
+		key, value, hasValue := strings.Cut(param, "=")
+		key = strings.ToLower(key)
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate SvcParam %q", key)
+		}
+		seen[key] = true
+
+		if err := validateSvcParam(key, value, hasValue); err != nil {
+			return nil, err
+		}
+		if hasValue {
+			normalized = append(normalized, key+`="`+value+`"`)
+		} else {
+			normalized = append(normalized, key)
+		}
+	}
+
+	return &SVCBData{
+		Priority: int(priority),
+		Target:   target,
+		Value:    strings.Join(normalized, " "),
+	}, nil
+}
+
+// splitSVCBFields splits presentation format on whitespace, keeping quoted
+// values together and removing the quotes
+func splitSVCBFields(content string) ([]string, error) {
+	var fields []string
+	var current strings.Builder
+	inQuotes := false
+	inField := false
+
+	for _, ch := range content {
+		switch {
+		case ch == '"':
+			inQuotes = !inQuotes
+			inField = true
+		case (ch == ' ' || ch == '\t') && !inQuotes:
+			if inField {
+				fields = append(fields, current.String())
+				current.Reset()
+				inField = false
+			}
+		default:
+			current.WriteRune(ch)
+			inField = true
+		}
+	}
+
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quote in %q", content)
+	}
+	if inField {
+		fields = append(fields, current.String())
+	}
+
+	return fields, nil
+}
+
+// validateSvcParam checks a single SvcParam key and its value
+func validateSvcParam(key, value string, hasValue bool) error {
+	if !svcParamKeys[key] && !isGenericSvcParamKey(key) {
+		return fmt.Errorf("unknown SvcParam key %q", key)
+	}
+
+	if key == "no-default-alpn" || key == "ohttp" {
+		if hasValue {
+			return fmt.Errorf("SvcParam %q does not take a value", key)
+		}
+		return nil
+	}
+	if !hasValue || value == "" {
+		if isGenericSvcParamKey(key) {
+			return nil
+		}
+		return fmt.Errorf("SvcParam %q requires a value", key)
+	}
+
+	switch key {
+	case "mandatory":
+		for _, item := range strings.Split(value, ",") {
+			if !svcParamKeys[item] && !isGenericSvcParamKey(item) {
+				return fmt.Errorf("invalid mandatory key %q", item)
+			}
+		}
+	case "alpn":
+		for _, item := range strings.Split(value, ",") {
+			if item == "" {
+				return fmt.Errorf("invalid alpn value %q", value)
+			}
+		}
+	case "port":
+		if _, err := strconv.ParseUint(value, 10, 16); err != nil {
+			return fmt.Errorf("invalid port %q: must be between 0 and 65535", value)
+		}
+	case "ipv4hint":
+		for _, item := range strings.Split(value, ",") {
+			if ip := net.ParseIP(item); ip == nil || ip.To4() == nil {
+				return fmt.Errorf("invalid ipv4hint address %q", item)
+			}
+		}
+	case "ipv6hint":
+		for _, item := range strings.Split(value, ",") {
+			if ip := net.ParseIP(item); ip == nil || !strings.Contains(item, ":") {
+				return fmt.Errorf("invalid ipv6hint address %q", item)
+			}
+		}
+	case "ech":
+		if _, err := base64.StdEncoding.DecodeString(value); err != nil {
+			return fmt.Errorf("invalid ech value: must be base64 encoded")
+		}
+	case "dohpath":
+		if !strings.HasPrefix(value, "/") || !strings.Contains(value, "{?dns}") {
+			return fmt.Errorf("invalid dohpath %q: must be a relative URI template containing {?dns}", value)
+		}
+	}
+
+	return nil
+}
+
+// isGenericSvcParamKey reports whether key uses the keyNNNNN form
+func isGenericSvcParamKey(key string) bool {
+	if !strings.HasPrefix(key, "key") {
+		return false
+	}
+	_, err := strconv.ParseUint(strings.TrimPrefix(key, "key"), 10, 16)
+	return err == nil
+}
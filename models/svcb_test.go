@@ -0,0 +1,62 @@
+package models
+
+import "testing"
+
+func TestParseSVCB(t *testing.T) {
+	t.Run("should parse service mode records", func(t *testing.T) {
+		data, err := ParseSVCB(`1 . alpn="h3,h2" ipv4hint=192.0.2.1,192.0.2.2 port=8443`)
+		if err != nil {
+			t.Fatalf("ParseSVCB() returned an error: %v", err)
+		}
+		if data.Priority != 1 {
+			t.Errorf("Priority is incorrect, got: %d, want: %d", data.Priority, 1)
+		}
+		if data.Target != "." {
+			t.Errorf("Target is incorrect, got: %s, want: %s", data.Target, ".")
+		}
+		want := `alpn="h3,h2" ipv4hint="192.0.2.1,192.0.2.2" port="8443"`
+		if data.Value != want {
+			t.Errorf("Value is incorrect, got: %s, want: %s", data.Value, want)
+		}
+	})
+
+	t.Run("should parse alias mode records", func(t *testing.T) {
+		data, err := ParseSVCB("0 svc.example.com.")
+		if err != nil {
+			t.Fatalf("ParseSVCB() returned an error: %v", err)
+		}
+		if data.String() != "0 svc.example.com." {
+			t.Errorf("String() is incorrect, got: %s, want: %s", data.String(), "0 svc.example.com.")
+		}
+	})
+
+	t.Run("should accept flag keys without values", func(t *testing.T) {
+		if _, err := ParseSVCB(`1 . alpn=h2 no-default-alpn`); err != nil {
+			t.Errorf("ParseSVCB() returned an error: %v", err)
+		}
+	})
+
+	invalid := map[string]string{
+		"missing target":      "1",
+		"invalid priority":    "70000 .",
+		"alias with params":   "0 . alpn=h2",
+		"unknown key":         "1 . foo=bar",
+		"duplicate key":       "1 . alpn=h2 alpn=h3",
+		"invalid port":        "1 . port=http",
+		"invalid ipv4hint":    "1 . ipv4hint=2001:db8::1",
+		"invalid ipv6hint":    "1 . ipv6hint=192.0.2.1",
+		"empty alpn":          `1 . alpn=""`,
+		"flag with value":     "1 . no-default-alpn=1",
+		"unterminated quote":  `1 . alpn="h2`,
+		"invalid ech":         "1 . ech=not-base64!",
+		"invalid mandatory":   "1 . mandatory=foo",
+		"missing param value": "1 . port",
+	}
+	for name, content := range invalid {
+		t.Run("should reject "+name, func(t *testing.T) {
+			if _, err := ParseSVCB(content); err == nil {
+				t.Errorf("ParseSVCB(%q) should have returned an error", content)
+			}
+		})
+	}
+}
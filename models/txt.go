@@ -0,0 +1,142 @@
+package models
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// TXTChunkSize is the maximum length in bytes of a single TXT character-string
+const TXTChunkSize = 255
+
+// FormatTXTContent quotes a TXT value, escaping embedded quotes and backslashes
+// and splitting values longer than TXTChunkSize into multiple quoted chunks.
+// Values that are already a sequence of quoted strings are kept as they are,
+// unless one of the strings is too long and has to be split.
+func FormatTXTContent(value string) string {
+	strs, ok := parseQuotedTXT(value)
+	if !ok {
+		return quoteTXTChunks(splitTXTValue(value))
+	}
+
+	for _, str := range strs {
+		if len(str) > TXTChunkSize {
+			return quoteTXTChunks(splitQuotedTXT(strs))
+		}
+	}
+	return value
+}
+
+// quoteTXTChunks quotes and escapes each chunk and joins them with spaces
+func quoteTXTChunks(chunks []string) string {
+	for i, chunk := range chunks {
+		chunks[i] = `"` + escapeTXTChunk(chunk) + `"`
+	}
+	return strings.Join(chunks, " ")
+}
+
+// splitQuotedTXT splits each of the unescaped strings into chunks of at most
+// TXTChunkSize bytes
+func splitQuotedTXT(strs []string) []string {
+	var chunks []string
+	for _, str := range strs {
+		chunks = append(chunks, splitTXTValue(str)...)
+	}
+	return chunks
+}
+
+// parseQuotedTXT parses a value in quoted presentation format, a sequence of
+// quoted character-strings separated by whitespace, and returns the unescaped
+// strings. It reports false if the value is not in that format.
+func parseQuotedTXT(value string) ([]string, bool) {
+	var strs []string
+	i := 0
+	for {
+		for i < len(value) && (value[i] == ' ' || value[i] == '\t') {
+			i++
+		}
+		if i == len(value) {
+			return strs, len(strs) > 0
+		}
+		if value[i] != '"' {
+			return nil, false
+		}
+		i++
+
+		var str strings.Builder
+		for {
+			if i == len(value) {
+				return nil, false
+			}
+			c := value[i]
+			if c == '"' {
+				i++
+				break
+			}
+			if c == '\\' {
+				if i+1 == len(value) {
+					return nil, false
+				}
+				if n, ok := decimalEscape(value[i+1:]); ok {
+					str.WriteByte(n)
+					i += 4
+					continue
+				}
+				c = value[i+1]
+				i++
+			}
+			str.WriteByte(c)
+			i++
+		}
+		strs = append(strs, str.String())
+
+		// Strings must be separated by whitespace
+		if i < len(value) && value[i] != ' ' && value[i] != '\t' {
+			return nil, false
+		}
+	}
+}
+
+// decimalEscape decodes the digits of a \DDD escape at the start of s
+func decimalEscape(s string) (byte, bool) {
+	if len(s) < 3 {
+		return 0, false
+	}
+	n := 0
+	for _, c := range []byte(s[:3]) {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+	}
+	if n > 255 {
+		return 0, false
+	}
+	return byte(n), true
+}
+
+// splitTXTValue splits a raw value into chunks of at most TXTChunkSize bytes
+// without breaking multi-byte characters
+func splitTXTValue(value string) []string {
+	if value == "" {
+		return []string{""}
+	}
+
+	var chunks []string
+	start := 0
+	for i := 0; i < len(value); {
+		_, size := utf8.DecodeRuneInString(value[i:])
+		if i+size-start > TXTChunkSize {
+			chunks = append(chunks, value[start:i])
+			start = i
+		}
+		i += size
+	}
+
+	return append(chunks, value[start:])
+}
+
+// escapeTXTChunk escapes backslashes and double quotes in a TXT chunk
+func escapeTXTChunk(chunk string) string {
+	chunk = strings.ReplaceAll(chunk, `\`, `\\`)
+	return strings.ReplaceAll(chunk, `"`, `\"`)
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatTXTContent(t *testing.T) {
+	t.Run("should quote short values", func(t *testing.T) {
+		got := FormatTXTContent("v=spf1 include:_spf.example.com ~all")
+		want := `"v=spf1 include:_spf.example.com ~all"`
+		if got != want {
+			t.Errorf("FormatTXTContent() = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("should escape embedded quotes and backslashes", func(t *testing.T) {
+		got := FormatTXTContent(`say "hi" \ bye`)
+		want := `"say \"hi\" \\ bye"`
+		if got != want {
+			t.Errorf("FormatTXTContent() = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("should leave already quoted values unchanged", func(t *testing.T) {
+		value := `"first" "second"`
+		if got := FormatTXTContent(value); got != value {
+			t.Errorf("FormatTXTContent() = %s, want %s", got, value)
+		}
+	})
+
+	t.Run("should escape values that are only quoted at the ends", func(t *testing.T) {
+		got := FormatTXTContent(`"a" and "b"`)
+		want := `"\"a\" and \"b\""`
+		if got != want {
+			t.Errorf("FormatTXTContent() = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("should leave quoted values with escapes unchanged", func(t *testing.T) {
+		value := `"say \"hi\"" "\065"`
+		if got := FormatTXTContent(value); got != value {
+			t.Errorf("FormatTXTContent() = %s, want %s", got, value)
+		}
+	})
+
+	t.Run("should split quoted strings longer than 255 characters", func(t *testing.T) {
+		value := `"` + strings.Repeat("a", 300) + `" "b"`
+		got := FormatTXTContent(value)
+		want := `"` + strings.Repeat("a", 255) + `" "` + strings.Repeat("a", 45) + `" "b"`
+		if got != want {
+			t.Errorf("FormatTXTContent() = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("should split values longer than 255 characters", func(t *testing.T) {
+		value := strings.Repeat("a", 300)
+		got := FormatTXTContent(value)
+		want := `"` + strings.Repeat("a", 255) + `" "` + strings.Repeat("a", 45) + `"`
+		if got != want {
+			t.Errorf("FormatTXTContent() = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("should not split multi-byte characters", func(t *testing.T) {
+		value := strings.Repeat("a", 254) + "é"
+		got := FormatTXTContent(value)
+		want := `"` + strings.Repeat("a", 254) + `" "é"`
+		if got != want {
+			t.Errorf("FormatTXTContent() = %s, want %s", got, want)
+		}
+	})
+}
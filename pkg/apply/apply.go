@@ -0,0 +1,348 @@
+// Package apply plans and applies DNS record changes through a
+// client.DNSProvider, for single records and for manifests
+package apply
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"yaca/client"
+	"yaca/models"
+	"yaca/pkg/logger"
+	"yaca/pkg/reconcile"
+	"yaca/pkg/utils"
+)
+
+// ErrOperationFailed is returned when the API reports a failure without an error
+var ErrOperationFailed = errors.New("operation failed unexpectedly")
+
+// Record creates, updates or deletes the record described by the
+// validated arguments and returns the changes made to the zone. In dry-run
+// mode the changes are only planned.
+func Record(ctx context.Context, provider client.DNSProvider, zoneID string, args models.Args, dryRun bool) ([]models.Change, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	changes, err := Plan(ctx, provider, zoneID, args)
+	if err != nil || dryRun {
+		return changes, err
+	}
+
+	return Execute(ctx, provider, zoneID, args.ZoneName, changes)
+}
+
+// Plan looks up the existing records and computes the changes needed
+// to reach the state described by the arguments, without modifying the zone
+func Plan(ctx context.Context, provider client.DNSProvider, zoneID string, args models.Args) ([]models.Change, error) {
+	record := RecordFromArgs(args)
+
+	if args.Delete {
+		// Look up every record of the name and type, and narrow them down
+		// by the fields given in the arguments
+		matches, err := provider.GetRecords(ctx, zoneID, models.Record{Record: record.Record, Type: record.Type})
+		if err != nil {
+			return nil, fmt.Errorf("failed to check record existence: %w", err)
+		}
+		return planDelete(args.ZoneName, record, filterForDelete(matches, args))
+	}
+
+	if models.IsRecordSetType(record.Type) {
+		return planRecordSet(ctx, provider, zoneID, recordSetFromArgs(args))
+	}
+
+	matches, err := provider.GetRecords(ctx, zoneID, record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check record existence: %w", err)
+	}
+
+	if len(matches) > 0 {
+		recordID := matches[0].RecordID
+		logger.Info("Record exists",
+			slog.String("record_name", record.Record),
+			slog.String("record_type", record.Type),
+			slog.String("zone_name", args.ZoneName),
+			slog.String("record_id", recordID)) // Will be masked
+
+		if len(matches) > 1 {
+			logger.Warn("Multiple matching records found, updating the first one",
+				slog.String("record_name", record.Record),
+				slog.String("record_type", record.Type),
+				slog.Int("matches", len(matches)))
+		}
+
+		if reconcile.Unchanged(matches[0].Record, record) {
+			logger.Info("Record is already up to date",
+				slog.String("record_name", record.Record),
+				slog.String("record_type", record.Type),
+				slog.String("zone_name", args.ZoneName),
+				slog.String("operation", "unchanged"))
+			return []models.Change{{Operation: models.OperationUnchanged, RecordID: recordID, Before: &matches[0].Record, After: &matches[0].Record}}, nil
+		}
+
+		return []models.Change{{Operation: models.OperationUpdated, RecordID: recordID, Before: &matches[0].Record, After: &record}}, nil
+	}
+
+	logger.Info("Record does not exist",
+		slog.String("record_name", record.Record),
+		slog.String("record_type", record.Type),
+		slog.String("zone_name", args.ZoneName))
+
+	return []models.Change{{Operation: models.OperationCreated, After: &record}}, nil
+}
+
+// planDelete plans the deletion of the matching records. Without a type, the
+// name must resolve to records of a single type so that unrelated records are
+// not removed.
+func planDelete(zoneName string, record models.Record, matches []models.RecordData) ([]models.Change, error) {
+	if len(matches) == 0 {
+		logger.Warn("Cannot delete non-existent record",
+			slog.String("record_name", record.Record),
+			slog.String("record_type", record.Type),
+			slog.String("zone_name", zoneName))
+		return nil, client.ErrRecordNotFound
+	}
+
+	if record.Type == "" {
+		for _, match := range matches[1:] {
+			if match.Record.Type != matches[0].Record.Type {
+				return nil, fmt.Errorf("record name has records of several types, set type to choose which to delete")
+			}
+		}
+	}
+
+	var changes []models.Change
+	for _, match := range matches {
+		changes = append(changes, models.Change{Operation: models.OperationDeleted, RecordID: match.RecordID, Before: &match.Record})
+	}
+	return changes, nil
+}
+
+// planRecordSet compares the desired set with the records sharing its name
+// and type, so that applying the changes makes them match exactly
+func planRecordSet(ctx context.Context, provider client.DNSProvider, zoneID string, desired []models.Record) ([]models.Change, error) {
+	recordName, recordType := desired[0].Record, desired[0].Type
+
+	existing, err := provider.ListRecords(ctx, zoneID, recordName, recordType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list records: %w", err)
+	}
+
+	plan := reconcile.Diff(desired, existing)
+	logger.Info("Record set compared",
+		slog.String("record_name", recordName),
+		slog.String("record_type", recordType),
+		slog.Int("create", len(plan.Create)),
+		slog.Int("update", len(plan.Update)),
+		slog.Int("keep", len(plan.Keep)),
+		slog.Int("delete", len(plan.Delete)))
+
+	previous := make(map[string]models.Record)
+	for _, recordData := range existing {
+		previous[recordData.RecordID] = recordData.Record
+	}
+
+	var changes []models.Change
+	for _, record := range plan.Create {
+		changes = append(changes, models.Change{Operation: models.OperationCreated, After: &record})
+	}
+	for _, recordData := range plan.Update {
+		before := previous[recordData.RecordID]
+		changes = append(changes, models.Change{Operation: models.OperationUpdated, RecordID: recordData.RecordID, Before: &before, After: &recordData.Record})
+	}
+	for _, recordData := range plan.Keep {
+		changes = append(changes, models.Change{Operation: models.OperationUnchanged, RecordID: recordData.RecordID, Before: &recordData.Record, After: &recordData.Record})
+	}
+	for _, recordData := range plan.Delete {
+		changes = append(changes, models.Change{Operation: models.OperationDeleted, RecordID: recordData.RecordID, Before: &recordData.Record})
+	}
+	return changes, nil
+}
+
+// Execute applies the planned changes in order and returns those that
+// were applied, stopping at the first failure or when the context is done
+func Execute(ctx context.Context, provider client.DNSProvider, zoneID, zoneName string, changes []models.Change) ([]models.Change, error) {
+	var applied []models.Change
+	for _, change := range changes {
+		if err := ctx.Err(); err != nil {
+			return applied, fmt.Errorf("stopped before applying all changes: %w", err)
+		}
+
+		var success bool
+		var err error
+
+		switch change.Operation {
+		case models.OperationCreated:
+			change.RecordID, err = provider.CreateRecord(ctx, zoneID, *change.After)
+			success = err == nil
+		case models.OperationUpdated:
+			success, err = provider.UpdateRecord(ctx, zoneID, change.RecordID, *change.After)
+		case models.OperationDeleted:
+			success, err = provider.DeleteRecord(ctx, zoneID, change.RecordID, *change.Before)
+		default:
+			applied = append(applied, change)
+			continue
+		}
+
+		if err != nil {
+			return applied, fmt.Errorf("failed to %s record: %w", models.OperationVerb(change.Operation), err)
+		}
+		if !success {
+			return applied, ErrOperationFailed
+		}
+
+		logger.Info("Record "+change.Operation+" successfully",
+			slog.String("record_name", change.Name()),
+			slog.String("zone_name", zoneName),
+			slog.String("operation", models.OperationVerb(change.Operation)))
+		applied = append(applied, change)
+	}
+
+	return applied, nil
+}
+
+// filterForDelete keeps the records matching the fields given to identify
+// the records to delete. CAA records are matched on their value, tag and
+// flags, and SRV records on their target, weight and port, each only when
+// given; other records are matched on content when a target is given.
+func filterForDelete(records []models.RecordData, args models.Args) []models.RecordData {
+	keys := make(map[string]bool)
+	if args.Target != "" && args.Type != "CAA" && args.Type != "SRV" {
+		targets := []models.Record{RecordFromArgs(args)}
+		if models.IsRecordSetType(args.Type) {
+			targets = recordSetFromArgs(args)
+		}
+		for _, target := range targets {
+			keys[reconcile.ContentKey(target)] = true
+		}
+	}
+
+	var filtered []models.RecordData
+	for _, record := range records {
+		switch {
+		case record.Record.Type == "CAA" && args.Type == "CAA":
+			if !matchesCAA(record.Record, args) {
+				continue
+			}
+		case record.Record.Type == "SRV" && args.Type == "SRV":
+			if !matchesSRV(record.Record, args) {
+				continue
+			}
+		case len(keys) > 0 && !keys[reconcile.ContentKey(record.Record)]:
+			continue
+		}
+		filtered = append(filtered, record)
+	}
+	return filtered
+}
+
+// matchesCAA reports whether the CAA record has the value, tag and flags
+// given in the arguments
+func matchesCAA(record models.Record, args models.Args) bool {
+	data, ok := models.ParseCAAContent(record.Target)
+	if record.CAA != nil {
+		data, ok = *record.CAA, true
+	}
+	if !ok {
+		return false
+	}
+
+	return (args.Target == "" || data.Value == args.Target) &&
+		(args.CAATag == "" || strings.EqualFold(data.Tag, args.CAATag)) &&
+		(args.CAAFlags == nil || data.Flags == *args.CAAFlags)
+}
+
+// matchesSRV reports whether the SRV record has the target, weight and port
+// given in the arguments. Records read from the API hold them in their
+// content, as "weight port target".
+func matchesSRV(record models.Record, args models.Args) bool {
+	target := record.Target
+	var weight, port int
+	if record.SRV != nil {
+		weight, port = record.SRV.Weight, record.SRV.Port
+	} else {
+		fields := strings.Fields(record.Target)
+		if len(fields) != 3 {
+			return false
+		}
+		var err error
+		if weight, err = strconv.Atoi(fields[0]); err != nil {
+			return false
+		}
+		if port, err = strconv.Atoi(fields[1]); err != nil {
+			return false
+		}
+		target = fields[2]
+	}
+
+	return (args.Target == "" || strings.EqualFold(strings.TrimSuffix(target, "."), strings.TrimSuffix(args.Target, "."))) &&
+		(args.Weight == nil || weight == *args.Weight) &&
+		(args.Port == nil || port == *args.Port)
+}
+
+// recordSetFromArgs builds one desired record per comma-separated target
+func recordSetFromArgs(args models.Args) []models.Record {
+	base := RecordFromArgs(args)
+
+	var records []models.Record
+	for _, target := range utils.SplitTargets(args.Target) {
+		record := base
+		record.Target = target
+		records = append(records, record)
+	}
+
+	return records
+}
+
+// RecordFromArgs builds the desired record from the parsed arguments
+func RecordFromArgs(args models.Args) models.Record {
+	record := models.Record{
+		Priority: args.Priority,
+		Record:   args.Record,
+		Proxy:    args.Proxy,
+		Target:   args.Target,
+		Ttl:      args.Ttl,
+		Type:     args.Type,
+	}
+
+	// A record to delete is identified by its full owner name and the fields
+	// matched by filterForDelete, so the SRV and CAA data are not built
+	if args.Type == "SRV" && !args.Delete {
+		record.SRV = &models.SRVData{
+			Service: args.Service,
+			Proto:   args.Proto,
+			Port:    derefInt(args.Port),
+			Weight:  derefInt(args.Weight),
+		}
+		record.Record = record.SRV.RecordName(args.Record)
+	}
+
+	if args.Type == "PTR" && (utils.IsIPAddress(args.Target) || utils.IsIPv6Address(args.Target)) {
+		if reverseName, err := utils.ReverseName(args.Target); err == nil {
+			record.Record = reverseName
+			record.Target = args.Record
+		}
+	}
+
+	if args.Type == "CAA" && !args.Delete {
+		record.CAA = &models.CAAData{
+			Flags: derefInt(args.CAAFlags),
+			Tag:   strings.ToLower(args.CAATag),
+			Value: args.Target,
+		}
+	}
+
+	return record
+}
+
+// derefInt returns the value of an optional integer argument, or zero if unset
+func derefInt(value *int) int {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
@@ -0,0 +1,273 @@
+package apply
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"yaca/client"
+	"yaca/models"
+	"yaca/pkg/cloudflaretest"
+	"yaca/pkg/utils"
+)
+
+// cancelingProvider cancels the context once it has created a record
+type cancelingProvider struct {
+	client.DNSProvider
+	cancel  context.CancelFunc
+	created int
+}
+
+func (p *cancelingProvider) CreateRecord(ctx context.Context, zoneID string, record models.Record) (string, error) {
+	p.created++
+	defer p.cancel()
+	return p.DNSProvider.CreateRecord(ctx, zoneID, record)
+}
+
+func TestRecordFromArgsComputesReverseName(t *testing.T) {
+	record := RecordFromArgs(models.Args{
+		Record:   "host.example.com",
+		ZoneName: "2.1.in-addr.arpa",
+		Target:   "1.2.3.4",
+		Type:     "PTR",
+	})
+
+	if record.Record != "4.3.2.1.in-addr.arpa" {
+		t.Errorf("Record is incorrect, got: %s, want: %s", record.Record, "4.3.2.1.in-addr.arpa")
+	}
+	if record.Target != "host.example.com" {
+		t.Errorf("Target is incorrect, got: %s, want: %s", record.Target, "host.example.com")
+	}
+}
+
+func TestPlanComputesUpdate(t *testing.T) {
+	ctx := context.Background()
+	provider := client.NewMemory("example.com")
+	zoneID, _ := provider.GetZoneIDByName(ctx, "example.com")
+	recordID, _ := provider.CreateRecord(ctx, zoneID, models.Record{Record: "www.example.com", Type: "CNAME", Target: "old.example.net", Ttl: 3600})
+
+	changes, err := Plan(ctx, provider, zoneID, models.Args{
+		Record:   "www.example.com",
+		ZoneName: "example.com",
+		Target:   "new.example.net",
+		Type:     "CNAME",
+		Ttl:      3600,
+	})
+
+	if err != nil {
+		t.Fatalf("Plan() returned an error: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Operation != models.OperationUpdated || changes[0].RecordID != recordID {
+		t.Fatalf("Expected an update of %s, got %+v", recordID, changes)
+	}
+	if changes[0].Before.Target != "old.example.net" || changes[0].After.Target != "new.example.net" {
+		t.Errorf("Unexpected before/after: %+v -> %+v", changes[0].Before, changes[0].After)
+	}
+	if records := provider.Records(zoneID); records[0].Record.Target != "old.example.net" {
+		t.Errorf("Expected the zone to be left untouched, got: %+v", records)
+	}
+}
+
+func TestExecuteStopsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	memory := client.NewMemory("example.com")
+	zoneID, _ := memory.GetZoneIDByName(ctx, "example.com")
+	provider := &cancelingProvider{DNSProvider: memory, cancel: cancel}
+
+	changes := []models.Change{
+		{Operation: models.OperationCreated, After: &models.Record{Record: "www.example.com", Type: "A", Target: "192.0.2.1"}},
+		{Operation: models.OperationCreated, After: &models.Record{Record: "www.example.com", Type: "A", Target: "192.0.2.2"}},
+	}
+
+	applied, err := Execute(ctx, provider, zoneID, "example.com", changes)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancellation error, got: %v", err)
+	}
+	if provider.created != 1 || len(applied) != 1 {
+		t.Errorf("Expected to stop after the first change, got %d created and %d applied", provider.created, len(applied))
+	}
+}
+
+func TestRecordWithMemoryProvider(t *testing.T) {
+	ctx := context.Background()
+	provider := client.NewMemory("example.com")
+	zoneID, _ := provider.GetZoneIDByName(ctx, "example.com")
+	args := models.Args{
+		Record:   "www.example.com",
+		ZoneName: "example.com",
+		Target:   "192.0.2.1,192.0.2.2",
+		Type:     "A",
+		Ttl:      3600,
+	}
+
+	changes, err := Record(ctx, provider, zoneID, args, false)
+	if err != nil || len(changes) != 2 || changes[0].Operation != models.OperationCreated {
+		t.Fatalf("Expected two records to be created, got: %+v, %v", changes, err)
+	}
+
+	changes, err = Record(ctx, provider, zoneID, args, false)
+	if err != nil || changes[0].Operation != models.OperationUnchanged || changes[1].Operation != models.OperationUnchanged {
+		t.Errorf("Expected a second run to change nothing, got: %+v, %v", changes, err)
+	}
+
+	args.Target = "192.0.2.1"
+	if _, err := Record(ctx, provider, zoneID, args, false); err != nil {
+		t.Fatalf("Record() returned an error: %v", err)
+	}
+	if records := provider.Records(zoneID); len(records) != 1 || records[0].Record.Target != "192.0.2.1" {
+		t.Errorf("Expected a single remaining record, got: %+v", records)
+	}
+
+	args.Delete = true
+	args.Target = ""
+	if _, err := Record(ctx, provider, zoneID, args, false); err != nil {
+		t.Fatalf("Record() returned an error: %v", err)
+	}
+	if records := provider.Records(zoneID); len(records) != 0 {
+		t.Errorf("Expected the record to be deleted, got: %+v", records)
+	}
+}
+
+func TestRecordDryRunDoesNotWrite(t *testing.T) {
+	ctx := context.Background()
+	provider := client.NewMemory("example.com")
+	zoneID, _ := provider.GetZoneIDByName(ctx, "example.com")
+
+	changes, err := Record(ctx, provider, zoneID, models.Args{Record: "www.example.com", ZoneName: "example.com", Target: "192.0.2.1", Type: "A", Ttl: 3600}, true)
+
+	if err != nil || len(changes) != 1 || changes[0].Operation != models.OperationCreated {
+		t.Fatalf("Expected a planned creation, got: %+v, %v", changes, err)
+	}
+	if records := provider.Records(zoneID); len(records) != 0 {
+		t.Errorf("Expected no records in dry-run mode, got: %+v", records)
+	}
+}
+
+func TestRecordDeletesByTarget(t *testing.T) {
+	intPtr := func(value int) *int { return &value }
+
+	tests := []struct {
+		name     string
+		existing []models.Record
+		args     models.Args
+		want     string
+	}{
+		{
+			name: "TXT",
+			existing: []models.Record{
+				{Record: "example.com", Type: "TXT", Target: "v=spf1 -all", Ttl: 3600},
+				{Record: "example.com", Type: "TXT", Target: "google-site-verification=abc", Ttl: 3600},
+			},
+			args: models.Args{Record: "example.com", Type: "TXT", Target: "google-site-verification=abc"},
+			want: "v=spf1 -all",
+		},
+		{
+			name: "MX",
+			existing: []models.Record{
+				{Record: "example.com", Type: "MX", Target: "mx1.example.com", Priority: intPtr(10), Ttl: 3600},
+				{Record: "example.com", Type: "MX", Target: "mx2.example.com", Priority: intPtr(20), Ttl: 3600},
+			},
+			args: models.Args{Record: "example.com", Type: "MX", Target: "mx2.example.com"},
+			want: "mx1.example.com",
+		},
+		{
+			name: "CAA by value",
+			existing: []models.Record{
+				{Record: "example.com", Type: "CAA", Target: "letsencrypt.org", CAA: &models.CAAData{Tag: "issue", Value: "letsencrypt.org"}, Ttl: 3600},
+				{Record: "example.com", Type: "CAA", Target: "digicert.com", CAA: &models.CAAData{Tag: "issue", Value: "digicert.com"}, Ttl: 3600},
+			},
+			args: models.Args{Record: "example.com", Type: "CAA", Target: "digicert.com"},
+			want: "letsencrypt.org",
+		},
+		{
+			name: "CAA by tag",
+			existing: []models.Record{
+				{Record: "example.com", Type: "CAA", Target: "letsencrypt.org", CAA: &models.CAAData{Tag: "issue", Value: "letsencrypt.org"}, Ttl: 3600},
+				{Record: "example.com", Type: "CAA", Target: "letsencrypt.org", CAA: &models.CAAData{Tag: "issuewild", Value: "letsencrypt.org"}, Ttl: 3600},
+			},
+			args: models.Args{Record: "example.com", Type: "CAA", Target: "letsencrypt.org", CAATag: "issuewild"},
+			want: "letsencrypt.org",
+		},
+		{
+			name: "SRV",
+			existing: []models.Record{
+				{Record: "_sip._tcp.example.com", Type: "SRV", Target: "sip1.example.com", Priority: intPtr(10), SRV: &models.SRVData{Service: "sip", Proto: "tcp", Weight: 5, Port: 5060}, Ttl: 3600},
+				{Record: "_sip._tcp.example.com", Type: "SRV", Target: "sip2.example.com", Priority: intPtr(10), SRV: &models.SRVData{Service: "sip", Proto: "tcp", Weight: 5, Port: 5060}, Ttl: 3600},
+			},
+			args: models.Args{Record: "_sip._tcp.example.com", Type: "SRV", Target: "sip2.example.com"},
+			want: "sip1.example.com",
+		},
+		{
+			name: "A",
+			existing: []models.Record{
+				{Record: "www.example.com", Type: "A", Target: "192.0.2.1", Ttl: 3600},
+				{Record: "www.example.com", Type: "A", Target: "192.0.2.2", Ttl: 3600},
+			},
+			args: models.Args{Record: "www.example.com", Type: "A", Target: "192.0.2.2"},
+			want: "192.0.2.1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			provider := client.NewMemory("example.com")
+			zoneID, _ := provider.GetZoneIDByName(ctx, "example.com")
+			for _, record := range test.existing {
+				provider.CreateRecord(ctx, zoneID, record)
+			}
+
+			args := test.args
+			args.ZoneName = "example.com"
+			args.Delete = true
+			if err := utils.ValidateArgs(&args); err != nil {
+				t.Fatalf("ValidateArgs() returned an error: %v", err)
+			}
+
+			changes, err := Record(ctx, provider, zoneID, args, false)
+
+			if err != nil || len(changes) != 1 {
+				t.Fatalf("Expected a single deletion, got: %+v, %v", changes, err)
+			}
+			records := provider.Records(zoneID)
+			if len(records) != 1 || records[0].Record.Target != test.want {
+				t.Errorf("Expected only %s to remain, got: %+v", test.want, records)
+			}
+		})
+	}
+}
+
+func TestRecordDeletesByTargetFromAPIContent(t *testing.T) {
+	ctx := context.Background()
+	server := cloudflaretest.NewServer()
+	defer server.Close()
+	zoneID := server.AddZone("example.com")
+	server.AddRecord(zoneID, cloudflaretest.Record{Name: "example.com", Type: "CAA", Content: `0 issue "letsencrypt.org"`, TTL: 3600})
+	server.AddRecord(zoneID, cloudflaretest.Record{Name: "example.com", Type: "CAA", Content: `0 issue "digicert.com"`, TTL: 3600})
+	server.AddRecord(zoneID, cloudflaretest.Record{Name: "_sip._tcp.example.com", Type: "SRV", Content: "5 5060 sip1.example.com", TTL: 3600})
+	server.AddRecord(zoneID, cloudflaretest.Record{Name: "_sip._tcp.example.com", Type: "SRV", Content: "5 5061 sip1.example.com", TTL: 3600})
+	provider := client.NewCloudflare(server.Client())
+
+	port := 5061
+	for _, args := range []models.Args{
+		{Record: "example.com", ZoneName: "example.com", Type: "CAA", Target: "digicert.com", Delete: true},
+		{Record: "_sip._tcp.example.com", ZoneName: "example.com", Type: "SRV", Target: "sip1.example.com", Port: &port, Delete: true},
+	} {
+		changes, err := Record(ctx, provider, zoneID, args, false)
+		if err != nil || len(changes) != 1 {
+			t.Errorf("Expected a single %s deletion, got: %+v, %v", args.Type, changes, err)
+		}
+	}
+
+	var contents []string
+	for _, record := range server.Records(zoneID) {
+		contents = append(contents, record.Content)
+	}
+	want := `0 issue "letsencrypt.org",5 5060 sip1.example.com`
+	if got := strings.Join(contents, ","); got != want {
+		t.Errorf("Unexpected zone contents, got: %s, want: %s", got, want)
+	}
+}
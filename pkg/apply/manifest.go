@@ -0,0 +1,185 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"yaca/client"
+	"yaca/models"
+	"yaca/pkg/logger"
+	"yaca/pkg/utils"
+)
+
+// Result is the outcome of applying a single manifest entry
+type Result struct {
+	Zone    string
+	ZoneID  string
+	Record  string
+	Type    string
+	Changes []models.Change
+	Err     error
+}
+
+// Manifest applies every record of a manifest, resolving each zone once, and
+// returns the outcome of each entry in order. A failed entry does not stop
+// the others. zoneIDs holds the IDs of zones already resolved, keyed by name;
+// the others are looked up. In dry-run mode the changes are only planned.
+func Manifest(ctx context.Context, provider client.DNSProvider, manifest *models.Manifest, zoneIDs map[string]string, dryRun bool) []Result {
+	var results []Result
+	for _, zone := range manifest.Zones {
+		entries := groupManifestRecords(zone)
+
+		zoneID, err := resolveZoneID(ctx, provider, zoneIDs, zone.Name)
+		if err != nil {
+			logger.Error("Failed to get zone ID",
+				slog.String("zone_name", zone.Name),
+				slog.String("error", err.Error()))
+			for _, args := range entries {
+				results = append(results, Result{Zone: zone.Name, Record: args.Record, Type: args.Type, Err: err})
+			}
+			continue
+		}
+
+		logger.Info("Zone retrieved",
+			slog.String("zone_id", zoneID),
+			slog.String("zone_name", zone.Name),
+			slog.Int("records", len(entries)))
+
+		for _, args := range entries {
+			result := Result{Zone: zone.Name, ZoneID: zoneID, Record: args.Record, Type: args.Type}
+			if err := utils.ValidateArgs(&args); err != nil {
+				result.Err = fmt.Errorf("%w: %w", utils.ErrInvalidArguments, err)
+			} else {
+				result.Changes, result.Err = Record(ctx, provider, zoneID, args, dryRun)
+			}
+			if result.Err != nil {
+				logger.Error("Failed to apply record",
+					slog.String("record_name", args.Record),
+					slog.String("record_type", args.Type),
+					slog.String("error", result.Err.Error()))
+			}
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
+
+// resolveZoneID returns the ID of the zone, looking it up unless it is
+// already known
+func resolveZoneID(ctx context.Context, provider client.DNSProvider, zoneIDs map[string]string, zoneName string) (string, error) {
+	if zoneID, ok := zoneIDs[zoneName]; ok {
+		return zoneID, nil
+	}
+	return provider.GetZoneIDByName(ctx, zoneName)
+}
+
+// groupManifestRecords converts the records of a zone into arguments. Entries
+// of set types sharing a name and type are merged into a single set, so that
+// reconciling one of them does not delete the others.
+func groupManifestRecords(zone models.ManifestZone) []models.Args {
+	var entries []models.Args
+	sets := make(map[string]int)
+
+	for _, record := range zone.Records {
+		args := argsFromManifestRecord(zone.Name, record)
+
+		if !args.Delete && models.IsRecordSetType(args.Type) {
+			key := strings.ToLower(args.Record) + "/" + args.Type
+			if i, ok := sets[key]; ok {
+				entries[i].Targets = append(entries[i].Targets, args.Targets...)
+				entries[i].Target = strings.Join(entries[i].Targets, ",")
+				continue
+			}
+			sets[key] = len(entries)
+		}
+
+		entries = append(entries, args)
+	}
+
+	return entries
+}
+
+// argsFromManifestRecord builds the arguments equivalent to a manifest entry,
+// so manifest records go through the same validation as the command line
+func argsFromManifestRecord(zoneName string, record models.ManifestRecord) models.Args {
+	args := models.Args{
+		Delete:   record.Delete,
+		Priority: record.Priority,
+		Record:   record.Record.Record,
+		Proxy:    record.Proxy,
+		Target:   record.Target,
+		Ttl:      record.Ttl,
+		Type:     strings.ToUpper(record.Type),
+		ZoneName: zoneName,
+	}
+
+	if args.Delete {
+		// Only the name, type and content identify a record to delete. SRV
+		// entries are looked up by their full owner name, which is built
+		// here only, and matched on target, weight and port; CAA entries
+		// are matched on value, tag and flags.
+		args.Priority, args.Proxy, args.Ttl = nil, false, 0
+		if record.SRV != nil {
+			args.Record = record.SRV.RecordName(args.Record)
+			args.Weight = &record.SRV.Weight
+			args.Port = &record.SRV.Port
+		}
+		if record.CAA != nil {
+			args.CAATag = record.CAA.Tag
+			args.CAAFlags = &record.CAA.Flags
+			if args.Target == "" {
+				args.Target = record.CAA.Value
+			}
+		}
+	} else {
+		if args.Ttl == 0 {
+			args.Ttl = 3600
+		}
+		if record.SRV != nil {
+			args.Service = record.SRV.Service
+			args.Proto = record.SRV.Proto
+			args.Weight = &record.SRV.Weight
+			args.Port = &record.SRV.Port
+		}
+		if record.CAA != nil {
+			args.CAAFlags = &record.CAA.Flags
+			args.CAATag = record.CAA.Tag
+			if args.Target == "" {
+				args.Target = record.CAA.Value
+			}
+		}
+	}
+	if args.Target != "" {
+		args.Targets = []string{args.Target}
+	}
+
+	return args
+}
+
+// Summary describes the outcome of the entry, e.g. "created 1, deleted 2".
+// Failures are described by their error code, as error messages can name
+// zones and records that are masked elsewhere.
+func (r Result) Summary() string {
+	if r.Err != nil {
+		return "failed: " + utils.ErrorCode(r.Err)
+	}
+
+	counts := make(map[string]int)
+	for _, change := range r.Changes {
+		counts[change.Operation]++
+	}
+
+	var parts []string
+	for _, operation := range []string{models.OperationCreated, models.OperationUpdated, models.OperationDeleted, models.OperationUnchanged} {
+		if counts[operation] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", operation, counts[operation]))
+		}
+	}
+	if len(parts) == 0 {
+		return models.OperationUnchanged
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,163 @@
+package apply
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"yaca/client"
+	"yaca/models"
+	"yaca/pkg/cloudflaretest"
+	"yaca/pkg/manifest"
+)
+
+func TestManifest(t *testing.T) {
+	ctx := context.Background()
+	provider := client.NewMemory("example.com")
+	zoneID, _ := provider.GetZoneIDByName(ctx, "example.com")
+	provider.CreateRecord(ctx, zoneID, models.Record{Record: "old.example.com", Type: "CNAME", Target: "example.net", Ttl: 3600})
+
+	loaded, err := manifest.Parse([]byte(`
+zones:
+  - name: example.com
+    records:
+      - name: www.example.com
+        type: A
+        content: 192.0.2.1
+      - name: www.example.com
+        type: A
+        content: 192.0.2.2
+      - name: old.example.com
+        type: CNAME
+        delete: true
+  - name: example.org
+    records:
+      - name: www.example.org
+        type: CNAME
+        content: example.net
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse manifest: %v", err)
+	}
+
+	results := Manifest(ctx, provider, loaded, nil, false)
+
+	if len(results) != 3 {
+		t.Fatalf("Expected one result per record set, got %d: %+v", len(results), results)
+	}
+	if results[0].Err != nil || len(results[0].Changes) != 2 || results[0].ZoneID != zoneID {
+		t.Errorf("Expected the A records to be created as one set, got: %+v", results[0])
+	}
+	if results[1].Err != nil || results[1].Changes[0].Operation != models.OperationDeleted {
+		t.Errorf("Expected the CNAME record to be deleted, got: %+v", results[1])
+	}
+	if !errors.Is(results[2].Err, client.ErrZoneNotFound) {
+		t.Errorf("Expected the unknown zone to fail, got: %v", results[2].Err)
+	}
+	if records := provider.Records(zoneID); len(records) != 2 {
+		t.Errorf("Expected two A records to remain, got: %+v", records)
+	}
+}
+
+func TestManifestDeletesSingleCAAAndSRVValues(t *testing.T) {
+	ctx := context.Background()
+	provider := client.NewMemory("example.com")
+	zoneID, _ := provider.GetZoneIDByName(ctx, "example.com")
+	priority := 10
+	for _, record := range []models.Record{
+		{Record: "example.com", Type: "CAA", Target: "letsencrypt.org", CAA: &models.CAAData{Tag: "issue", Value: "letsencrypt.org"}, Ttl: 3600},
+		{Record: "example.com", Type: "CAA", Target: "digicert.com", CAA: &models.CAAData{Tag: "issue", Value: "digicert.com"}, Ttl: 3600},
+		{Record: "_sip._tcp.example.com", Type: "SRV", Target: "sip1.example.com", Priority: &priority, SRV: &models.SRVData{Service: "sip", Proto: "tcp", Weight: 5, Port: 5060}, Ttl: 3600},
+		{Record: "_sip._tcp.example.com", Type: "SRV", Target: "sip2.example.com", Priority: &priority, SRV: &models.SRVData{Service: "sip", Proto: "tcp", Weight: 5, Port: 5060}, Ttl: 3600},
+	} {
+		provider.CreateRecord(ctx, zoneID, record)
+	}
+
+	loaded, err := manifest.Parse([]byte(`
+zones:
+  - name: example.com
+    records:
+      - name: example.com
+        type: CAA
+        caa:
+          flags: 0
+          tag: issue
+          value: digicert.com
+        delete: true
+      - name: example.com
+        type: SRV
+        content: sip2.example.com
+        priority: 10
+        srv:
+          service: sip
+          proto: tcp
+          weight: 5
+          port: 5060
+        delete: true
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse manifest: %v", err)
+	}
+
+	results := Manifest(ctx, provider, loaded, nil, false)
+
+	for _, result := range results {
+		if result.Err != nil || len(result.Changes) != 1 {
+			t.Errorf("Expected a single %s deletion, got: %+v", result.Type, result)
+		}
+	}
+	var remaining []string
+	for _, record := range provider.Records(zoneID) {
+		remaining = append(remaining, record.Record.Target)
+	}
+	if got, want := strings.Join(remaining, ","), "letsencrypt.org,sip1.example.com"; got != want {
+		t.Errorf("Unexpected remaining records, got: %s, want: %s", got, want)
+	}
+}
+
+func TestManifestReusesKnownZoneIDs(t *testing.T) {
+	ctx := context.Background()
+	server := cloudflaretest.NewServer()
+	defer server.Close()
+	zoneID := server.AddZone("example.com")
+
+	loaded, err := manifest.Parse([]byte(`
+zones:
+  - name: example.com
+    records:
+      - name: www.example.com
+        type: CNAME
+        content: example.net
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse manifest: %v", err)
+	}
+
+	results := Manifest(ctx, client.NewCloudflare(server.Client()), loaded, map[string]string{"example.com": zoneID}, false)
+
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("Expected the record to be applied, got: %+v", results)
+	}
+	for _, request := range server.Requests() {
+		if request.Path == "/zones" {
+			t.Errorf("Expected the known zone not to be looked up")
+		}
+	}
+}
+
+func TestResultSummary(t *testing.T) {
+	result := Result{Changes: []models.Change{
+		{Operation: models.OperationCreated},
+		{Operation: models.OperationCreated},
+		{Operation: models.OperationDeleted},
+	}}
+	if got, want := result.Summary(), "created 2, deleted 1"; got != want {
+		t.Errorf("Summary() is incorrect, got: %s, want: %s", got, want)
+	}
+
+	result = Result{Err: fmt.Errorf("%w: no zone found with name: example.com", client.ErrZoneNotFound)}
+	if got, want := result.Summary(), "failed: zone_not_found"; got != want {
+		t.Errorf("Summary() is incorrect, got: %s, want: %s", got, want)
+	}
+}
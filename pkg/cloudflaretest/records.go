@@ -0,0 +1,307 @@
+package cloudflaretest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Page sizes used when the request does not set per_page
+const (
+	zonesPerPage   = 20
+	recordsPerPage = 100
+)
+
+// proxiableTypes lists the record types that can be proxied
+var proxiableTypes = map[string]bool{"A": true, "AAAA": true, "CNAME": true}
+
+// supportedTypes lists the record types the server accepts
+var supportedTypes = map[string]bool{
+	"A": true, "AAAA": true, "CAA": true, "CNAME": true, "HTTPS": true, "MX": true,
+	"NS": true, "PTR": true, "SRV": true, "SVCB": true, "TXT": true,
+}
+
+// recordBody is the body of a create or update request. Pointers tell
+// fields left out of a PATCH apart from zero values.
+type recordBody struct {
+	Name     *string        `json:"name"`
+	Type     *string        `json:"type"`
+	Content  *string        `json:"content"`
+	Proxied  *bool          `json:"proxied"`
+	TTL      *float64       `json:"ttl"`
+	Priority *float64       `json:"priority"`
+	Data     map[string]any `json:"data"`
+}
+
+// listZones handles GET /zones, filtered by the name query parameter
+func (s *Server) listZones(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := strings.ToLower(r.URL.Query().Get("name"))
+	var zones []Zone
+	for _, zone := range s.zones {
+		if name == "" || zone.Name == name {
+			zones = append(zones, zone)
+		}
+	}
+
+	page, info := paginate(r, zones, zonesPerPage)
+	writeResult(w, page, info)
+}
+
+// listRecords handles GET /zones/{zone}/dns_records, filtered by the name,
+// type and content query parameters
+func (s *Server) listRecords(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, ok := s.records[r.PathValue("zone")]
+	if !ok {
+		writeZoneNotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	name := firstOf(query.Get("name.exact"), query.Get("name"))
+	content := firstOf(query.Get("content.exact"), query.Get("content"))
+	recordType := query.Get("type")
+
+	var matches []Record
+	for _, record := range records {
+		if (name == "" || strings.EqualFold(record.Name, name)) &&
+			(recordType == "" || record.Type == recordType) &&
+			(content == "" || record.Content == content) {
+			matches = append(matches, record)
+		}
+	}
+
+	page, info := paginate(r, matches, recordsPerPage)
+	writeResult(w, page, info)
+}
+
+// getRecord handles GET /zones/{zone}/dns_records/{id}
+func (s *Server) getRecord(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findRecord(w, r)
+	if !ok {
+		return
+	}
+	writeResult(w, s.records[r.PathValue("zone")][i], nil)
+}
+
+// createRecord handles POST /zones/{zone}/dns_records
+func (s *Server) createRecord(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zoneID := r.PathValue("zone")
+	zone, ok := s.zone(zoneID)
+	if !ok {
+		writeZoneNotFound(w, r)
+		return
+	}
+
+	var body recordBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, 9207, "Request body is invalid.")
+		return
+	}
+
+	record := Record{ZoneID: zoneID, ZoneName: zone.Name}
+	applyBody(&record, body)
+	if !s.validate(w, zone, &record) {
+		return
+	}
+
+	record.ID = s.newID()
+	s.records[zoneID] = append(s.records[zoneID], record)
+	writeResult(w, record, nil)
+}
+
+// updateRecord handles PUT and PATCH /zones/{zone}/dns_records/{id}. PUT
+// replaces the record while PATCH only changes the fields that are set.
+func (s *Server) updateRecord(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findRecord(w, r)
+	if !ok {
+		return
+	}
+	zoneID := r.PathValue("zone")
+	zone, _ := s.zone(zoneID)
+
+	var body recordBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, 9207, "Request body is invalid.")
+		return
+	}
+
+	record := s.records[zoneID][i]
+	if r.Method == http.MethodPut {
+		record = Record{ID: record.ID, ZoneID: zoneID, ZoneName: zone.Name}
+	}
+	applyBody(&record, body)
+	if !s.validate(w, zone, &record) {
+		return
+	}
+
+	s.records[zoneID][i] = record
+	writeResult(w, record, nil)
+}
+
+// deleteRecord handles DELETE /zones/{zone}/dns_records/{id}
+func (s *Server) deleteRecord(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.findRecord(w, r)
+	if !ok {
+		return
+	}
+
+	zoneID := r.PathValue("zone")
+	id := s.records[zoneID][i].ID
+	s.records[zoneID] = append(s.records[zoneID][:i], s.records[zoneID][i+1:]...)
+	writeResult(w, map[string]string{"id": id}, nil)
+}
+
+// findRecord returns the index of the record addressed by the request, or
+// writes an error response if it does not exist; the lock must be held
+func (s *Server) findRecord(w http.ResponseWriter, r *http.Request) (int, bool) {
+	records, ok := s.records[r.PathValue("zone")]
+	if !ok {
+		writeZoneNotFound(w, r)
+		return 0, false
+	}
+
+	for i, record := range records {
+		if record.ID == r.PathValue("id") {
+			return i, true
+		}
+	}
+	writeError(w, http.StatusNotFound, 81044, "Record does not exist.")
+	return 0, false
+}
+
+// validate normalizes the record and checks it the way the API does, writing
+// an error response if it is rejected; the lock must be held
+func (s *Server) validate(w http.ResponseWriter, zone Zone, record *Record) bool {
+	if !supportedTypes[record.Type] {
+		writeError(w, http.StatusBadRequest, 9000, fmt.Sprintf("DNS record type %q is invalid.", record.Type))
+		return false
+	}
+	if record.Name == "" {
+		writeError(w, http.StatusBadRequest, 9007, "DNS record name is required.")
+		return false
+	}
+	if record.Content == "" {
+		writeError(w, http.StatusBadRequest, 9005, "Content for the DNS record is required.")
+		return false
+	}
+
+	record.Name = qualify(record.Name, zone.Name)
+	record.Proxiable = proxiableTypes[record.Type]
+	if record.Proxied && !record.Proxiable {
+		writeError(w, http.StatusBadRequest, 9004, "This record type cannot be proxied.")
+		return false
+	}
+	// Proxied records and records without a TTL use the automatic TTL
+	if record.Proxied || record.TTL == 0 {
+		record.TTL = 1
+	}
+
+	for _, existing := range s.records[zone.ID] {
+		if existing.ID == record.ID || !strings.EqualFold(existing.Name, record.Name) {
+			continue
+		}
+		if existing.Type == record.Type && existing.Content == record.Content {
+			writeError(w, http.StatusBadRequest, 81058, "An identical record already exists.")
+			return false
+		}
+		// A CNAME cannot share its name with any other record
+		if existing.Type == "CNAME" || record.Type == "CNAME" {
+			writeError(w, http.StatusBadRequest, 81053, "An A, AAAA, or CNAME record with that host already exists.")
+			return false
+		}
+	}
+	return true
+}
+
+// applyBody copies the fields set in the request body onto the record. The
+// content of types described by structured data is derived from it.
+func applyBody(record *Record, body recordBody) {
+	if body.Name != nil {
+		record.Name = *body.Name
+	}
+	if body.Type != nil {
+		record.Type = *body.Type
+	}
+	if body.Content != nil {
+		record.Content = *body.Content
+	}
+	if body.Proxied != nil {
+		record.Proxied = *body.Proxied
+	}
+	if body.TTL != nil {
+		record.TTL = *body.TTL
+	}
+	if body.Priority != nil {
+		record.Priority = body.Priority
+	}
+	if body.Data != nil {
+		record.Data = body.Data
+		if content := dataContent(record.Type, body.Data); content != "" {
+			record.Content = content
+		}
+		if priority, ok := body.Data["priority"].(float64); ok && record.Type == "SRV" {
+			record.Priority = &priority
+		}
+	}
+}
+
+// dataContent returns the content the API shows for a record described by
+// structured data
+func dataContent(recordType string, data map[string]any) string {
+	switch recordType {
+	case "CAA":
+		return fmt.Sprintf("%v %v %q", data["flags"], data["tag"], data["value"])
+	case "HTTPS", "SVCB":
+		return fmt.Sprintf("%v %v %v", data["priority"], data["target"], data["value"])
+	case "SRV":
+		return fmt.Sprintf("%v %v %v", data["weight"], data["port"], data["target"])
+	}
+	return ""
+}
+
+// qualify returns the fully qualified form of a record name within the zone
+func qualify(name, zoneName string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	switch {
+	case name == "@" || name == zoneName:
+		return zoneName
+	case strings.HasSuffix(name, "."+zoneName):
+		return name
+	default:
+		return name + "." + zoneName
+	}
+}
+
+// writeZoneNotFound writes the error returned for an unknown zone ID
+func writeZoneNotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusNotFound, 7003, fmt.Sprintf("Could not route to %s, perhaps your object identifier is invalid?", r.URL.Path))
+}
+
+// firstOf returns the first non-empty value
+func firstOf(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,349 @@
+// Package cloudflaretest provides a fake Cloudflare API server for tests. It
+// implements the token verification, zones and DNS records endpoints used by
+// yaca on top of an in-memory state, with pagination, and can be told to fail
+// or rate limit requests, so that complete scenarios run offline.
+package cloudflaretest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go/v4"
+	"github.com/cloudflare/cloudflare-go/v4/option"
+)
+
+// Zone is a zone held by the server
+type Zone struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Permissions lists what the caller may do on the zone, such as
+	// "#dns_records:edit"; the API leaves it out when empty
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// Token is the API token reported by the token verification endpoint
+type Token struct {
+	ID        string
+	Status    string
+	ExpiresOn time.Time
+	NotBefore time.Time
+}
+
+// Record is a DNS record held by the server, in the shape returned by the API
+type Record struct {
+	ID        string         `json:"id"`
+	ZoneID    string         `json:"zone_id"`
+	ZoneName  string         `json:"zone_name"`
+	Name      string         `json:"name"`
+	Type      string         `json:"type"`
+	Content   string         `json:"content"`
+	Proxiable bool           `json:"proxiable"`
+	Proxied   bool           `json:"proxied"`
+	TTL       float64        `json:"ttl"`
+	Priority  *float64       `json:"priority,omitempty"`
+	Data      map[string]any `json:"data,omitempty"`
+}
+
+// Request describes a request received by the server
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+}
+
+// Fault makes the server answer matching requests with an error instead of
+// handling them
+type Fault struct {
+	// Method matches the HTTP method of the request; empty matches any
+	Method string
+	// Path matches the request path with path.Match, e.g.
+	// "/zones/*/dns_records"; empty matches any
+	Path string
+	// Status is the HTTP status of the error response
+	Status int
+	// Code and Message describe the error in the response body
+	Code    int
+	Message string
+	// RetryAfter sets the Retry-After header when it is not zero
+	RetryAfter time.Duration
+	// Times is the number of requests to fail; zero fails every request
+	Times int
+}
+
+// Server is a fake Cloudflare API. Its zero value is not usable; create one
+// with NewServer and close it when done.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	token    Token
+	zones    []Zone
+	records  map[string][]Record
+	faults   []*Fault
+	requests []Request
+	nextID   int
+}
+
+// NewServer starts a fake Cloudflare API with no zones and an active token
+func NewServer() *Server {
+	s := &Server{records: make(map[string][]Record)}
+	s.token = Token{ID: s.newID(), Status: "active"}
+	s.Server = httptest.NewServer(s.handler())
+	return s
+}
+
+// Client returns a Cloudflare API client talking to the server. The SDK's own
+// retries are disabled so that tests see every failure; options given here
+// are applied last.
+func (s *Server) Client(opts ...option.RequestOption) *cloudflare.Client {
+	return cloudflare.NewClient(append([]option.RequestOption{
+		option.WithBaseURL(s.URL),
+		option.WithAPIToken("test-token"),
+		option.WithMaxRetries(0),
+	}, opts...)...)
+}
+
+// AddZone adds an empty zone and returns its ID
+func (s *Server) AddZone(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	zone := Zone{ID: s.newID(), Name: strings.ToLower(name)}
+	s.zones = append(s.zones, zone)
+	s.records[zone.ID] = nil
+	return zone.ID
+}
+
+// SetZonePermissions sets the permissions reported for a zone
+func (s *Server) SetZonePermissions(zoneID string, permissions ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.zones {
+		if s.zones[i].ID == zoneID {
+			s.zones[i].Permissions = permissions
+		}
+	}
+}
+
+// SetToken sets the token reported by the token verification endpoint
+func (s *Server) SetToken(token Token) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = token
+}
+
+// AddRecord adds a record to a zone, bypassing validation, and returns its
+// ID. The zone and ID fields are filled in.
+func (s *Server) AddRecord(zoneID string, record Record) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record.ID = s.newID()
+	record.ZoneID = zoneID
+	if zone, ok := s.zone(zoneID); ok {
+		record.ZoneName = zone.Name
+	}
+	s.records[zoneID] = append(s.records[zoneID], record)
+	return record.ID
+}
+
+// Records returns a copy of the records of a zone, in creation order
+func (s *Server) Records(zoneID string) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Record(nil), s.records[zoneID]...)
+}
+
+// Requests returns the requests received so far, including failed ones
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// Fail makes the server answer requests matching the fault with its error
+func (s *Server) Fail(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &fault)
+}
+
+// RateLimit makes the server reject the next requests with 429 Too Many
+// Requests, asking clients to wait retryAfter before trying again
+func (s *Server) RateLimit(times int, retryAfter time.Duration) {
+	s.Fail(Fault{
+		Status:     http.StatusTooManyRequests,
+		Code:       971,
+		Message:    "Please wait and consider throttling your request speed",
+		RetryAfter: retryAfter,
+		Times:      times,
+	})
+}
+
+// handler returns the handler routing API requests
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /user/tokens/verify", s.verifyToken)
+	mux.HandleFunc("GET /zones", s.listZones)
+	mux.HandleFunc("GET /zones/{zone}/dns_records", s.listRecords)
+	mux.HandleFunc("POST /zones/{zone}/dns_records", s.createRecord)
+	mux.HandleFunc("GET /zones/{zone}/dns_records/{id}", s.getRecord)
+	mux.HandleFunc("PUT /zones/{zone}/dns_records/{id}", s.updateRecord)
+	mux.HandleFunc("PATCH /zones/{zone}/dns_records/{id}", s.updateRecord)
+	mux.HandleFunc("DELETE /zones/{zone}/dns_records/{id}", s.deleteRecord)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, 7003, fmt.Sprintf("Could not route to %s, perhaps your object identifier is invalid?", r.URL.Path))
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Accept the public base URL as well as the bare server URL
+		r.URL.Path = strings.TrimPrefix(r.URL.Path, "/client/v4")
+
+		if fault := s.record(r); fault != nil {
+			if fault.RetryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(fault.RetryAfter.Seconds())))
+			}
+			writeError(w, fault.Status, fault.Code, fault.Message)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// verifyToken handles GET /user/tokens/verify
+func (s *Server) verifyToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := map[string]string{"id": s.token.ID, "status": s.token.Status}
+	if !s.token.ExpiresOn.IsZero() {
+		result["expires_on"] = s.token.ExpiresOn.Format(time.RFC3339)
+	}
+	if !s.token.NotBefore.IsZero() {
+		result["not_before"] = s.token.NotBefore.Format(time.RFC3339)
+	}
+	writeResult(w, result, nil)
+}
+
+// record logs the request and returns the fault to answer it with, if any
+func (s *Server) record(r *http.Request) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query()})
+
+	for i, fault := range s.faults {
+		if fault.Method != "" && fault.Method != r.Method {
+			continue
+		}
+		if fault.Path != "" {
+			if ok, _ := path.Match(fault.Path, r.URL.Path); !ok {
+				continue
+			}
+		}
+
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return fault
+	}
+	return nil
+}
+
+// zone returns the zone with the given ID; the lock must be held
+func (s *Server) zone(zoneID string) (Zone, bool) {
+	for _, zone := range s.zones {
+		if zone.ID == zoneID {
+			return zone, true
+		}
+	}
+	return Zone{}, false
+}
+
+// newID returns a new identifier shaped like the API's; the lock must be held
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("%032x", s.nextID)
+}
+
+// resultInfo describes the page of a list response
+type resultInfo struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Count      int `json:"count"`
+	TotalCount int `json:"total_count"`
+	TotalPages int `json:"total_pages"`
+}
+
+// apiError is an error in the body of a failed response
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// envelope is the body of every API response
+type envelope struct {
+	Result     any         `json:"result"`
+	ResultInfo *resultInfo `json:"result_info,omitempty"`
+	Success    bool        `json:"success"`
+	Errors     []apiError  `json:"errors"`
+	Messages   []string    `json:"messages"`
+}
+
+// writeResult writes a successful response
+func writeResult(w http.ResponseWriter, result any, info *resultInfo) {
+	writeJSON(w, http.StatusOK, envelope{Result: result, ResultInfo: info, Success: true, Errors: []apiError{}, Messages: []string{}})
+}
+
+// writeError writes a failed response with a single error
+func writeError(w http.ResponseWriter, status, code int, message string) {
+	writeJSON(w, status, envelope{Errors: []apiError{{Code: code, Message: message}}, Messages: []string{}})
+}
+
+// writeJSON writes the response body
+func writeJSON(w http.ResponseWriter, status int, body envelope) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// paginate returns the requested page of items, using the page and per_page
+// query parameters
+func paginate[T any](r *http.Request, items []T, defaultPerPage int) ([]T, *resultInfo) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if err != nil || perPage < 1 {
+		perPage = defaultPerPage
+	}
+
+	start := min((page-1)*perPage, len(items))
+	end := min(start+perPage, len(items))
+	result := append([]T{}, items[start:end]...)
+
+	return result, &resultInfo{
+		Page:       page,
+		PerPage:    perPage,
+		Count:      len(result),
+		TotalCount: len(items),
+		TotalPages: (len(items) + perPage - 1) / perPage,
+	}
+}
@@ -0,0 +1,151 @@
+package cloudflaretest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"yaca/client"
+	"yaca/models"
+	"yaca/pkg/cloudflaretest"
+	"yaca/pkg/config"
+)
+
+// newProvider starts a server with one zone and returns a provider using it
+func newProvider(t *testing.T) (*cloudflaretest.Server, *client.Cloudflare, string) {
+	t.Setenv("RETRY_BACKOFF", "1ms")
+	config.Load()
+
+	server := cloudflaretest.NewServer()
+	t.Cleanup(server.Close)
+	zoneID := server.AddZone("example.com")
+
+	return server, client.NewCloudflare(server.Client()), zoneID
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should look up zones by name", func(t *testing.T) {
+		_, provider, zoneID := newProvider(t)
+
+		got, err := provider.GetZoneIDByName(ctx, "example.com")
+		if err != nil || got != zoneID {
+			t.Errorf("GetZoneIDByName() = %q, %v, want: %q", got, err, zoneID)
+		}
+
+		_, err = provider.GetZoneIDByName(ctx, "example.net")
+		if !errors.Is(err, client.ErrZoneNotFound) {
+			t.Errorf("Expected ErrZoneNotFound, got: %v", err)
+		}
+	})
+
+	t.Run("should keep the state of records", func(t *testing.T) {
+		server, provider, zoneID := newProvider(t)
+		record := models.Record{Record: "www.example.com", Type: "A", Target: "192.0.2.1", Ttl: 3600}
+
+		recordID, err := provider.CreateRecord(ctx, zoneID, record)
+		if err != nil {
+			t.Fatalf("CreateRecord() returned an error: %v", err)
+		}
+
+		record.Target = "192.0.2.2"
+		record.Proxy = true
+		if _, err := provider.UpdateRecord(ctx, zoneID, recordID, record); err != nil {
+			t.Fatalf("UpdateRecord() returned an error: %v", err)
+		}
+
+		records, err := provider.GetRecords(ctx, zoneID, record)
+		if err != nil || len(records) != 1 {
+			t.Fatalf("GetRecords() = %+v, %v", records, err)
+		}
+		if got := records[0].Record; got.Target != "192.0.2.2" || !got.Proxy || got.Ttl != 1 {
+			t.Errorf("Expected the updated, proxied record with an automatic TTL, got: %+v", got)
+		}
+
+		if _, err := provider.DeleteRecord(ctx, zoneID, recordID, record); err != nil {
+			t.Fatalf("DeleteRecord() returned an error: %v", err)
+		}
+		if remaining := server.Records(zoneID); len(remaining) != 0 {
+			t.Errorf("Expected no records left, got: %+v", remaining)
+		}
+	})
+
+	t.Run("should derive the content of SRV records from their data", func(t *testing.T) {
+		server, provider, zoneID := newProvider(t)
+		priority := 10
+		record := models.Record{
+			Record:   "_sip._tcp.example.com",
+			Type:     "SRV",
+			Target:   "sip.example.com",
+			Ttl:      3600,
+			Priority: &priority,
+			SRV:      &models.SRVData{Service: "_sip", Proto: "_tcp", Port: 5060, Weight: 5},
+		}
+
+		if _, err := provider.CreateRecord(ctx, zoneID, record); err != nil {
+			t.Fatalf("CreateRecord() returned an error: %v", err)
+		}
+
+		records := server.Records(zoneID)
+		if len(records) != 1 || records[0].Content != "5 5060 sip.example.com" || records[0].Priority == nil || *records[0].Priority != 10 {
+			t.Errorf("Unexpected SRV record: %+v", records)
+		}
+	})
+
+	t.Run("should reject identical records as conflicts", func(t *testing.T) {
+		_, provider, zoneID := newProvider(t)
+		record := models.Record{Record: "example.com", Type: "TXT", Target: "v=spf1 -all", Ttl: 3600}
+
+		if _, err := provider.CreateRecord(ctx, zoneID, record); err != nil {
+			t.Fatalf("CreateRecord() returned an error: %v", err)
+		}
+		_, err := provider.CreateRecord(ctx, zoneID, record)
+		if !errors.Is(err, client.ErrConflict) {
+			t.Errorf("Expected ErrConflict, got: %v", err)
+		}
+	})
+
+	t.Run("should paginate record lists", func(t *testing.T) {
+		server, provider, zoneID := newProvider(t)
+		for i := range 250 {
+			server.AddRecord(zoneID, cloudflaretest.Record{Name: "example.com", Type: "TXT", Content: fmt.Sprintf("token-%d", i), TTL: 1})
+		}
+
+		records, err := provider.ListRecords(ctx, zoneID, "example.com", "TXT")
+		if err != nil || len(records) != 250 {
+			t.Errorf("ListRecords() returned %d records, %v", len(records), err)
+		}
+		if requests := server.Requests(); len(requests) != 3 {
+			t.Errorf("Expected 3 page requests, got %d", len(requests))
+		}
+	})
+
+	t.Run("should retry rate limited requests", func(t *testing.T) {
+		server, provider, zoneID := newProvider(t)
+		server.RateLimit(1, 0)
+
+		_, err := provider.CreateRecord(ctx, zoneID, models.Record{Record: "www.example.com", Type: "A", Target: "192.0.2.1", Ttl: 3600})
+		if err != nil {
+			t.Fatalf("CreateRecord() returned an error: %v", err)
+		}
+		if len(server.Requests()) != 2 || len(server.Records(zoneID)) != 1 {
+			t.Errorf("Expected the record to be created on the second attempt, got requests: %+v", server.Requests())
+		}
+	})
+
+	t.Run("should inject failures on matching requests", func(t *testing.T) {
+		server, provider, zoneID := newProvider(t)
+		recordID := server.AddRecord(zoneID, cloudflaretest.Record{Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 1})
+		server.Fail(cloudflaretest.Fault{Method: http.MethodDelete, Path: "/zones/*/dns_records/*", Status: http.StatusForbidden, Code: 10000, Message: "Authentication error"})
+
+		_, err := provider.DeleteRecord(ctx, zoneID, recordID, models.Record{Record: "www.example.com", Type: "A"})
+		if !errors.Is(err, client.ErrAuth) {
+			t.Errorf("Expected ErrAuth, got: %v", err)
+		}
+		if len(server.Records(zoneID)) != 1 {
+			t.Errorf("Expected the record to be kept")
+		}
+	})
+}
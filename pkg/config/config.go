@@ -1,7 +1,12 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds the application configuration
@@ -10,19 +15,32 @@ type Config struct {
 	Debug         bool
 	MaskSensitive bool
 	Environment   string
+
+	// Retry policy for Cloudflare API calls: attempts include the first
+	// call, and the backoff doubles after each retry up to the maximum
+	RetryMaxAttempts int
+	RetryBackoff     time.Duration
+	RetryMaxBackoff  time.Duration
 }
 
 var AppConfig *Config
 
-// Load initializes the configuration from environment variables
-func Load() *Config {
+// Load initializes the configuration from environment variables. Invalid
+// retry settings are reported in the returned error; the defaults are used
+// in their place.
+func Load() (*Config, error) {
+	var errs []error
 	AppConfig = &Config{
 		LogLevel:      getEnvOrDefault("LOG_LEVEL", "INFO"),
 		Debug:         getEnvOrDefault("DEBUG", "false") == "true",
 		MaskSensitive: getEnvOrDefault("MASK_SENSITIVE", "true") == "true",
 		Environment:   getEnvOrDefault("ENVIRONMENT", "development"),
+
+		RetryMaxAttempts: getIntEnvOrDefault("RETRY_MAX_ATTEMPTS", 3, &errs),
+		RetryBackoff:     getDurationEnvOrDefault("RETRY_BACKOFF", time.Second, &errs),
+		RetryMaxBackoff:  getDurationEnvOrDefault("RETRY_MAX_BACKOFF", 30*time.Second, &errs),
 	}
-	return AppConfig
+	return AppConfig, errors.Join(errs...)
 }
 
 // getEnvOrDefault returns the environment variable value or a default
@@ -32,3 +50,34 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getIntEnvOrDefault returns the environment variable as a positive integer,
+// or a default if it is unset. Invalid values are added to errs.
+func getIntEnvOrDefault(key string, defaultValue int, errs *[]error) int {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 {
+		*errs = append(*errs, fmt.Errorf("%s must be a positive integer, got %q", key, raw))
+		return defaultValue
+	}
+	return value
+}
+
+// getDurationEnvOrDefault returns the environment variable as a duration such
+// as "500ms" or "2s", or a default if it is unset. Invalid values are added
+// to errs.
+func getDurationEnvOrDefault(key string, defaultValue time.Duration, errs *[]error) time.Duration {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return defaultValue
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil || value < 0 {
+		*errs = append(*errs, fmt.Errorf("%s must be a duration such as 500ms or 2s, got %q", key, raw))
+		return defaultValue
+	}
+	return value
+}
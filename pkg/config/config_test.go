@@ -0,0 +1,38 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("should read the retry settings", func(t *testing.T) {
+		t.Setenv("RETRY_MAX_ATTEMPTS", "5")
+		t.Setenv("RETRY_BACKOFF", "500ms")
+		t.Setenv("RETRY_MAX_BACKOFF", "")
+
+		cfg, err := Load()
+
+		if err != nil {
+			t.Fatalf("Load() returned an error: %v", err)
+		}
+		if cfg.RetryMaxAttempts != 5 || cfg.RetryBackoff != 500*time.Millisecond || cfg.RetryMaxBackoff != 30*time.Second {
+			t.Errorf("Unexpected retry settings, got: %+v", cfg)
+		}
+	})
+
+	t.Run("should reject invalid retry settings", func(t *testing.T) {
+		t.Setenv("RETRY_MAX_ATTEMPTS", "0")
+		t.Setenv("RETRY_BACKOFF", "5")
+		t.Setenv("RETRY_MAX_BACKOFF", "abc")
+
+		_, err := Load()
+
+		for _, want := range []string{"RETRY_MAX_ATTEMPTS must be a positive integer", "RETRY_BACKOFF must be a duration", "RETRY_MAX_BACKOFF must be a duration"} {
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("Expected an error containing %q, got: %v", want, err)
+			}
+		}
+	})
+}
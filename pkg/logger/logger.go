@@ -6,10 +6,20 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"sync"
 )
 
 var Logger *slog.Logger
 
+// redacted replaces credentials in the logs
+const redacted = "***REDACTED***"
+
+// secrets holds the credential values registered with RedactSecrets
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
 // Init initializes the logger with appropriate settings
 func Init() {
 	logLevel := slog.LevelInfo
@@ -39,6 +49,11 @@ func Init() {
 }
 
 func maskSensitiveData(groups []string, a slog.Attr) slog.Attr {
+	// Credentials are redacted even with masking disabled
+	if a.Value.Kind() == slog.KindString {
+		a.Value = slog.StringValue(redactSecrets(a.Value.String()))
+	}
+
 	if os.Getenv("DISABLE_LOG_MASKING") == "true" {
 		return a
 	}
@@ -52,8 +67,8 @@ func maskSensitiveData(groups []string, a slog.Attr) slog.Attr {
 		if str, ok := a.Value.Any().(string); ok {
 			a.Value = slog.StringValue(MaskDomain(str))
 		}
-	case "api_token", "api_email", "api_key":
-		a.Value = slog.StringValue("***REDACTED***")
+	case "api_token", "api_email", "api_key", "global_api_key", "authorization":
+		a.Value = slog.StringValue(redacted)
 	case "email":
 		if str, ok := a.Value.Any().(string); ok {
 			a.Value = slog.StringValue(MaskEmail(str))
@@ -66,6 +81,36 @@ func maskSensitiveData(groups []string, a slog.Attr) slog.Attr {
 	return a
 }
 
+// RedactSecrets registers credential values, such as an API token or key, to
+// be replaced wherever they appear in logged strings
+func RedactSecrets(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	for _, value := range values {
+		if value != "" {
+			secrets = append(secrets, value)
+		}
+	}
+}
+
+// redactSecrets replaces the registered credentials found in the value
+func redactSecrets(value string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+
+	for _, secret := range secrets {
+		value = strings.ReplaceAll(value, secret, redacted)
+	}
+	return value
+}
+
+// MaskValue applies the masking rules of the given log key to a value, so
+// output written outside of the logger follows the same rules
+func MaskValue(key, value string) string {
+	return maskSensitiveData(nil, slog.String(key, value)).Value.String()
+}
+
 // MaskID masks an ID showing only first and last 4 characters
 func MaskID(id string) string {
 	if len(id) <= 8 {
@@ -106,8 +151,17 @@ func MaskEmail(email string) string {
 	return localPart + "@" + parts[1]
 }
 
-// MaskIP masks an IP address showing only the first octet
+// MaskIP masks an IP address showing only the first octet (IPv4) or
+// the first hextet (IPv6)
 func MaskIP(ip string) string {
+	if strings.Contains(ip, ":") {
+		parts := strings.Split(ip, ":")
+		if parts[0] != "" {
+			return parts[0] + ":****:****:****"
+		}
+		return "****:****:****"
+	}
+
 	parts := strings.Split(ip, ".")
 	if len(parts) == 4 {
 		return parts[0] + ".***.***.***"
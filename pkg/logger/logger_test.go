@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestMaskSensitiveData(t *testing.T) {
+	RedactSecrets("global-api-key-value")
+
+	t.Run("should redact registered credentials anywhere", func(t *testing.T) {
+		t.Setenv("DISABLE_LOG_MASKING", "true")
+
+		attr := maskSensitiveData(nil, slog.String("error", "request failed with key global-api-key-value"))
+		if got := attr.Value.String(); got != "request failed with key ***REDACTED***" {
+			t.Errorf("Credential was not redacted, got: %s", got)
+		}
+	})
+
+	t.Run("should redact credential keys", func(t *testing.T) {
+		for _, key := range []string{"api_token", "api_key", "global_api_key"} {
+			if got := MaskValue(key, "secret"); got != "***REDACTED***" {
+				t.Errorf("%s was not redacted, got: %s", key, got)
+			}
+		}
+	})
+}
+
+func TestMaskIP(t *testing.T) {
+	tests := map[string]string{
+		"192.0.2.1":   "192.***.***.***",
+		"2001:db8::1": "2001:****:****:****",
+		"::1":         "****:****:****",
+		"not-an-ip":   "***.***.***",
+	}
+
+	for ip, want := range tests {
+		if got := MaskIP(ip); got != want {
+			t.Errorf("MaskIP(%q) = %s, want %s", ip, got, want)
+		}
+	}
+}
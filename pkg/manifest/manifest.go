@@ -0,0 +1,57 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"yaca/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+var Load = load
+
+// load reads a manifest from a YAML or JSON file. Unknown fields are rejected
+// so that typos do not silently drop settings.
+func load(path string) (*models.Manifest, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	return Parse(content)
+}
+
+// Parse decodes a YAML or JSON manifest and checks that every zone has a name
+// and every record a name and a type
+func Parse(content []byte) (*models.Manifest, error) {
+	var manifest models.Manifest
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&manifest); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	if len(manifest.Zones) == 0 {
+		return nil, fmt.Errorf("manifest does not contain any zone")
+	}
+	for i, zone := range manifest.Zones {
+		if zone.Name == "" {
+			return nil, fmt.Errorf("zone %d: name is required", i+1)
+		}
+		for j, record := range zone.Records {
+			if record.Record.Record == "" {
+				return nil, fmt.Errorf("zone %s, record %d: name is required", zone.Name, j+1)
+			}
+			if record.Type == "" {
+				return nil, fmt.Errorf("zone %s, record %d: type is required", zone.Name, j+1)
+			}
+		}
+	}
+
+	return &manifest, nil
+}
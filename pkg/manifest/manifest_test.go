@@ -0,0 +1,110 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("should parse a YAML manifest", func(t *testing.T) {
+		manifest, err := Parse([]byte(`
+zones:
+  - name: example.com
+    records:
+      - name: www.example.com
+        type: A
+        content: 192.0.2.1
+        ttl: 300
+        proxied: true
+      - name: example.com
+        type: MX
+        content: mail.example.com
+        priority: 10
+      - name: old.example.com
+        type: CNAME
+        delete: true
+`))
+		if err != nil {
+			t.Fatalf("Parse() returned an error: %v", err)
+		}
+
+		if len(manifest.Zones) != 1 || len(manifest.Zones[0].Records) != 3 {
+			t.Fatalf("Unexpected manifest layout: %+v", manifest)
+		}
+		records := manifest.Zones[0].Records
+		if records[0].Target != "192.0.2.1" || records[0].Ttl != 300 || !records[0].Proxy {
+			t.Errorf("Unexpected first record: %+v", records[0])
+		}
+		if records[1].Priority == nil || *records[1].Priority != 10 {
+			t.Errorf("Expected MX priority 10, got %v", records[1].Priority)
+		}
+		if !records[2].Delete {
+			t.Errorf("Expected third record to be marked for deletion")
+		}
+	})
+
+	t.Run("should parse a JSON manifest with structured data", func(t *testing.T) {
+		manifest, err := Parse([]byte(`{"zones": [{"name": "example.com", "records": [
+			{"name": "example.com", "type": "SRV", "content": "sip.example.com", "priority": 10,
+			 "srv": {"service": "sip", "proto": "tcp", "weight": 5, "port": 5060}},
+			{"name": "example.com", "type": "CAA", "caa": {"flags": 0, "tag": "issue", "value": "letsencrypt.org"}}
+		]}]}`))
+		if err != nil {
+			t.Fatalf("Parse() returned an error: %v", err)
+		}
+
+		records := manifest.Zones[0].Records
+		if records[0].SRV == nil || records[0].SRV.Port != 5060 {
+			t.Errorf("Expected SRV data to be parsed, got %+v", records[0].SRV)
+		}
+		if records[1].CAA == nil || records[1].CAA.Value != "letsencrypt.org" {
+			t.Errorf("Expected CAA data to be parsed, got %+v", records[1].CAA)
+		}
+	})
+
+	t.Run("should reject unknown fields", func(t *testing.T) {
+		_, err := Parse([]byte("zones:\n  - name: example.com\n    records:\n      - name: www\n        type: A\n        target: 192.0.2.1\n"))
+		if err == nil || !strings.Contains(err.Error(), "target") {
+			t.Errorf("Expected an unknown field error, got %v", err)
+		}
+	})
+
+	t.Run("should reject records without a type", func(t *testing.T) {
+		_, err := Parse([]byte("zones:\n  - name: example.com\n    records:\n      - name: www.example.com\n"))
+		if err == nil || !strings.Contains(err.Error(), "type is required") {
+			t.Errorf("Expected a missing type error, got %v", err)
+		}
+	})
+
+	t.Run("should reject an empty manifest", func(t *testing.T) {
+		if _, err := Parse([]byte("")); err == nil {
+			t.Errorf("Expected an error for an empty manifest")
+		}
+	})
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("should read the manifest from a file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "records.yaml")
+		content := "zones:\n  - name: example.com\n    records:\n      - name: www.example.com\n        type: CNAME\n        content: example.com\n"
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal("Failed to write manifest:", err)
+		}
+
+		manifest, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load() returned an error: %v", err)
+		}
+		if manifest.Zones[0].Records[0].Target != "example.com" {
+			t.Errorf("Unexpected record: %+v", manifest.Zones[0].Records[0])
+		}
+	})
+
+	t.Run("should fail when the file does not exist", func(t *testing.T) {
+		if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+			t.Errorf("Expected an error for a missing file")
+		}
+	})
+}
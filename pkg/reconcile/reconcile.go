@@ -0,0 +1,174 @@
+package reconcile
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"yaca/models"
+)
+
+// Plan holds the changes needed to turn an existing record set into the desired one
+type Plan struct {
+	Create []models.Record
+	Update []models.RecordData
+	Keep   []models.RecordData
+	Delete []models.RecordData
+}
+
+// HasChanges reports whether applying the plan would modify the zone
+func (p Plan) HasChanges() bool {
+	return len(p.Create) > 0 || len(p.Update) > 0 || len(p.Delete) > 0
+}
+
+// Diff compares the desired records with the existing records sharing their
+// name and type. Records are matched on content; matched records whose TTL,
+// proxy status or priority differ are updated in place. Unmatched existing
+// records are reused for unmatched desired records where possible, so a
+// changed value is edited rather than deleted and recreated, and the rest
+// are deleted.
+func Diff(desired []models.Record, existing []models.RecordData) Plan {
+	var plan Plan
+	var unmatched []models.Record
+
+	available := make(map[string][]models.RecordData)
+	for _, record := range existing {
+		key := ContentKey(record.Record)
+		available[key] = append(available[key], record)
+	}
+
+	matched := make(map[string]bool)
+	seen := make(map[string]bool)
+	for _, record := range desired {
+		key := ContentKey(record)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		candidates := available[key]
+		if len(candidates) == 0 {
+			unmatched = append(unmatched, record)
+			continue
+		}
+
+		current := candidates[0]
+		available[key] = candidates[1:]
+		matched[current.RecordID] = true
+
+		if NeedsUpdate(current.Record, record) {
+			plan.Update = append(plan.Update, models.RecordData{
+				ZoneID:   current.ZoneID,
+				RecordID: current.RecordID,
+				Record:   record,
+			})
+		} else {
+			plan.Keep = append(plan.Keep, current)
+		}
+	}
+
+	for _, record := range existing {
+		if matched[record.RecordID] {
+			continue
+		}
+		if len(unmatched) > 0 {
+			plan.Update = append(plan.Update, models.RecordData{
+				ZoneID:   record.ZoneID,
+				RecordID: record.RecordID,
+				Record:   unmatched[0],
+			})
+			unmatched = unmatched[1:]
+			continue
+		}
+		plan.Delete = append(plan.Delete, record)
+	}
+
+	plan.Create = append(plan.Create, unmatched...)
+
+	return plan
+}
+
+// NeedsUpdate reports whether the settings of an existing record differ from
+// the desired record with the same content. The TTL of proxied records is
+// always automatic on Cloudflare, so it is not compared for them.
+func NeedsUpdate(current, desired models.Record) bool {
+	if current.Proxy != desired.Proxy {
+		return true
+	}
+	if !desired.Proxy && current.Ttl != desired.Ttl {
+		return true
+	}
+	if desired.Priority != nil && (current.Priority == nil || *current.Priority != *desired.Priority) {
+		return true
+	}
+	return false
+}
+
+// Unchanged reports whether an existing record already matches the desired
+// record, so that writing it again would not modify the zone
+func Unchanged(current, desired models.Record) bool {
+	return current.Type == desired.Type &&
+		ContentKey(current) == ContentKey(desired) &&
+		!NeedsUpdate(current, desired)
+}
+
+// ContentKey returns a normalized form of the record content used to match
+// desired and existing records
+func ContentKey(record models.Record) string {
+	content := record.Content()
+
+	switch record.Type {
+	case "A", "AAAA":
+		if ip := net.ParseIP(content); ip != nil {
+			return ip.String()
+		}
+		return content
+	case "CNAME", "MX", "NS", "PTR":
+		return strings.ToLower(strings.TrimSuffix(content, "."))
+	default:
+		return content
+	}
+}
+
+// FieldChange describes the value of a single record field before and after
+// a change. Before is empty for created records and After for deleted ones.
+type FieldChange struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// Fields returns the fields that differ between two versions of a record. A
+// nil record stands for a record that does not exist, so every field of the
+// other one is reported.
+func Fields(before, after *models.Record) []FieldChange {
+	beforeFields, afterFields := fieldValues(before), fieldValues(after)
+
+	var changes []FieldChange
+	for i, field := range fieldNames {
+		if beforeFields[i] != afterFields[i] {
+			changes = append(changes, FieldChange{Field: field, Before: beforeFields[i], After: afterFields[i]})
+		}
+	}
+	return changes
+}
+
+// fieldNames lists the record fields compared by Fields, in display order
+var fieldNames = []string{"type", "content", "ttl", "proxied", "priority"}
+
+// fieldValues returns the displayed value of each field in fieldNames
+func fieldValues(record *models.Record) []string {
+	values := make([]string, len(fieldNames))
+	if record == nil {
+		return values
+	}
+
+	values[0] = record.Type
+	values[1] = record.Content()
+	values[2] = strconv.FormatFloat(record.Ttl, 'f', -1, 64)
+	values[3] = strconv.FormatBool(record.Proxy)
+	if record.Priority != nil {
+		values[4] = strconv.Itoa(*record.Priority)
+	}
+	return values
+}
@@ -0,0 +1,154 @@
+package reconcile
+
+import (
+	"testing"
+	"yaca/models"
+)
+
+func nsRecord(target string) models.Record {
+	return models.Record{Record: "dev.example.com", Type: "NS", Target: target, Ttl: 3600}
+}
+
+func TestDiff(t *testing.T) {
+	t.Run("should create missing and delete stale records", func(t *testing.T) {
+		desired := []models.Record{nsRecord("ns1.example.net"), nsRecord("ns2.example.net"), nsRecord("ns4.example.net")}
+		existing := []models.RecordData{
+			{RecordID: "id-1", Record: nsRecord("NS1.example.net.")},
+			{RecordID: "id-3", Record: nsRecord("ns3.example.net")},
+		}
+
+		plan := Diff(desired, existing)
+
+		if len(plan.Keep) != 1 || plan.Keep[0].RecordID != "id-1" {
+			t.Errorf("Keep is incorrect, got: %+v", plan.Keep)
+		}
+		if len(plan.Update) != 1 || plan.Update[0].RecordID != "id-3" || plan.Update[0].Record.Target != "ns2.example.net" {
+			t.Errorf("Update is incorrect, got: %+v", plan.Update)
+		}
+		if len(plan.Create) != 1 || plan.Create[0].Target != "ns4.example.net" {
+			t.Errorf("Create is incorrect, got: %+v", plan.Create)
+		}
+		if len(plan.Delete) != 0 {
+			t.Errorf("Delete should be empty, got: %+v", plan.Delete)
+		}
+	})
+
+	t.Run("should delete records removed from the set", func(t *testing.T) {
+		desired := []models.Record{nsRecord("ns1.example.net")}
+		existing := []models.RecordData{
+			{RecordID: "id-1", Record: nsRecord("ns1.example.net")},
+			{RecordID: "id-2", Record: nsRecord("ns2.example.net")},
+		}
+
+		plan := Diff(desired, existing)
+
+		if len(plan.Delete) != 1 || plan.Delete[0].RecordID != "id-2" {
+			t.Errorf("Delete is incorrect, got: %+v", plan.Delete)
+		}
+		if len(plan.Create) != 0 || len(plan.Update) != 0 {
+			t.Errorf("Create and Update should be empty, got: %+v", plan)
+		}
+	})
+
+	t.Run("should update records whose settings changed", func(t *testing.T) {
+		changed := nsRecord("ns1.example.net")
+		changed.Ttl = 300
+		existing := []models.RecordData{{RecordID: "id-1", Record: nsRecord("ns1.example.net")}}
+
+		plan := Diff([]models.Record{changed}, existing)
+
+		if len(plan.Update) != 1 || plan.Update[0].RecordID != "id-1" || plan.Update[0].Record.Ttl != 300 {
+			t.Errorf("Update is incorrect, got: %+v", plan.Update)
+		}
+		if !plan.HasChanges() {
+			t.Error("HasChanges() should be true")
+		}
+	})
+
+	t.Run("should report no changes when the set matches", func(t *testing.T) {
+		existing := []models.RecordData{{RecordID: "id-1", Record: nsRecord("ns1.example.net")}}
+
+		plan := Diff([]models.Record{nsRecord("ns1.example.net"), nsRecord("ns1.example.net")}, existing)
+
+		if plan.HasChanges() {
+			t.Errorf("HasChanges() should be false, got: %+v", plan)
+		}
+	})
+}
+
+func TestUnchanged(t *testing.T) {
+	t.Run("should match records with the same content and settings", func(t *testing.T) {
+		current := models.Record{Record: "www.example.com", Type: "CNAME", Target: "Example.net.", Ttl: 300}
+		desired := models.Record{Record: "www.example.com", Type: "CNAME", Target: "example.net", Ttl: 300}
+
+		if !Unchanged(current, desired) {
+			t.Errorf("Expected records to be unchanged")
+		}
+	})
+
+	t.Run("should detect changed content, TTL or proxy status", func(t *testing.T) {
+		current := models.Record{Record: "www.example.com", Type: "CNAME", Target: "example.net", Ttl: 300}
+
+		for _, desired := range []models.Record{
+			{Record: "www.example.com", Type: "CNAME", Target: "example.org", Ttl: 300},
+			{Record: "www.example.com", Type: "CNAME", Target: "example.net", Ttl: 3600},
+			{Record: "www.example.com", Type: "CNAME", Target: "example.net", Ttl: 300, Proxy: true},
+		} {
+			if Unchanged(current, desired) {
+				t.Errorf("Expected %+v to differ from %+v", desired, current)
+			}
+		}
+	})
+
+	t.Run("should ignore the TTL of proxied records", func(t *testing.T) {
+		current := models.Record{Record: "www.example.com", Type: "CNAME", Target: "example.net", Ttl: 1, Proxy: true}
+		desired := models.Record{Record: "www.example.com", Type: "CNAME", Target: "example.net", Ttl: 3600, Proxy: true}
+
+		if !Unchanged(current, desired) {
+			t.Errorf("Expected proxied records with automatic TTL to be unchanged")
+		}
+	})
+}
+
+func TestFields(t *testing.T) {
+	t.Run("should report only the fields that changed", func(t *testing.T) {
+		before := models.Record{Record: "www.example.com", Type: "A", Target: "192.0.2.1", Ttl: 300, Proxy: true}
+		after := models.Record{Record: "www.example.com", Type: "A", Target: "192.0.2.2", Ttl: 3600, Proxy: true}
+
+		changes := Fields(&before, &after)
+
+		expected := []FieldChange{
+			{Field: "content", Before: "192.0.2.1", After: "192.0.2.2"},
+			{Field: "ttl", Before: "300", After: "3600"},
+		}
+		if len(changes) != len(expected) {
+			t.Fatalf("Expected %d changes, got: %+v", len(expected), changes)
+		}
+		for i := range expected {
+			if changes[i] != expected[i] {
+				t.Errorf("Expected %+v, got %+v", expected[i], changes[i])
+			}
+		}
+	})
+
+	t.Run("should report every field of a created record", func(t *testing.T) {
+		priority := 10
+		after := models.Record{Record: "example.com", Type: "MX", Target: "mail.example.com", Ttl: 3600, Priority: &priority}
+
+		changes := Fields(nil, &after)
+
+		if len(changes) != 5 {
+			t.Fatalf("Expected all fields to be reported, got: %+v", changes)
+		}
+		if changes[4].Field != "priority" || changes[4].Before != "" || changes[4].After != "10" {
+			t.Errorf("Priority is incorrect, got: %+v", changes[4])
+		}
+	})
+
+	t.Run("should report nothing for identical records", func(t *testing.T) {
+		record := models.Record{Record: "example.com", Type: "TXT", Target: "v=spf1 -all", Ttl: 3600}
+		if changes := Fields(&record, &record); len(changes) != 0 {
+			t.Errorf("Expected no changes, got: %+v", changes)
+		}
+	})
+}
@@ -8,10 +8,13 @@ import (
 	"yaca/pkg/logger"
 )
 
+// HandleError logs the error, records its error_code step output and exits
+// with the exit code of its class
 func HandleError(err error, msg string, args ...any) {
 	if err != nil {
 		args = append(args, slog.String("error", err.Error()))
 		args = append(args, slog.String("error_type", fmt.Sprintf("%T", err)))
+		args = append(args, slog.String("error_code", ErrorCode(err)))
 		
 		logger.Error(msg, args...)
 		
@@ -20,7 +23,12 @@ func HandleError(err error, msg string, args ...any) {
 				slog.String("stack", string(debug.Stack())))
 		}
 		
-		os.Exit(1)
+		if outputErr := WriteGitHubOutputs(map[string]string{"error_code": ErrorCode(err)}); outputErr != nil {
+			logger.Warn("Failed to write error code output",
+				slog.String("error", outputErr.Error()))
+		}
+
+		os.Exit(ExitCode(err))
 	}
 }
 
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"context"
+	"errors"
+)
+
+// Exit codes returned by yaca, documented in the README
+const (
+	ExitOK           = 0
+	ExitFailure      = 1
+	ExitValidation   = 2
+	ExitAuth         = 3
+	ExitZoneNotFound = 4
+	ExitConflict     = 5
+	ExitRateLimited  = 6
+	ExitServerError  = 7
+	ExitTimeout      = 8
+	ExitCanceled     = 9
+)
+
+// ErrInvalidArguments marks errors caused by the arguments, inputs or
+// manifest given to yaca
+var ErrInvalidArguments = errors.New("invalid arguments")
+
+// sentinelErrors maps the errors matched with errors.Is to their exit code
+// and error_code step output. They are checked before the codes reported by
+// client errors, as an API call cut short by a timeout or cancellation also
+// carries the class of the failure it was retrying.
+var sentinelErrors = []struct {
+	err      error
+	exitCode int
+	code     string
+}{
+	{context.DeadlineExceeded, ExitTimeout, "timeout"},
+	{context.Canceled, ExitCanceled, "canceled"},
+	{ErrInvalidArguments, ExitValidation, "validation"},
+}
+
+// codeExitCodes maps the error_code reported by the error classes of the
+// client, see codedError, to its exit code
+var codeExitCodes = map[string]int{
+	"validation":     ExitValidation,
+	"auth":           ExitAuth,
+	"zone_not_found": ExitZoneNotFound,
+	"conflict":       ExitConflict,
+	"rate_limited":   ExitRateLimited,
+	"server_error":   ExitServerError,
+	// A record to delete that does not exist keeps its own error_code but
+	// exits like any other failure
+	"record_not_found": ExitFailure,
+}
+
+// codedError is implemented by the error classes of the client, which name
+// their error_code step output
+type codedError interface {
+	error
+	ErrorCode() string
+}
+
+// ExitCode returns the exit code for an error, or ExitOK for nil
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	exitCode, _ := classify(err)
+	return exitCode
+}
+
+// ErrorCode returns the error_code step output for an error, or an empty
+// string for nil
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	_, code := classify(err)
+	return code
+}
+
+// classify returns the exit code and error_code of an error: those of the
+// first matching sentinel error, then those of the outermost code reported
+// in its tree, and a general failure otherwise
+func classify(err error) (int, string) {
+	for _, sentinel := range sentinelErrors {
+		if errors.Is(err, sentinel.err) {
+			return sentinel.exitCode, sentinel.code
+		}
+	}
+	if code := reportedCode(err); code != "" {
+		if exitCode, ok := codeExitCodes[code]; ok {
+			return exitCode, code
+		}
+	}
+	return ExitFailure, "failure"
+}
+
+// reportedCode returns the code reported by the first codedError found in
+// the tree of err, walked depth-first, or an empty string if there is none
+func reportedCode(err error) string {
+	if coded, ok := err.(codedError); ok {
+		return coded.ErrorCode()
+	}
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() error }:
+		if inner := wrapped.Unwrap(); inner != nil {
+			return reportedCode(inner)
+		}
+	case interface{ Unwrap() []error }:
+		for _, inner := range wrapped.Unwrap() {
+			if code := reportedCode(inner); code != "" {
+				return code
+			}
+		}
+	}
+	return ""
+}
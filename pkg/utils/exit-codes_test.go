@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"yaca/client"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		exitCode int
+		code     string
+	}{
+		{"should succeed without an error", nil, ExitOK, ""},
+		{"should map invalid arguments", fmt.Errorf("%w: record is required", ErrInvalidArguments), ExitValidation, "validation"},
+		{"should map rejected requests", &client.APIError{Class: client.ErrValidation, Err: errors.New("bad request")}, ExitValidation, "validation"},
+		{"should map authentication failures", &client.APIError{Class: client.ErrAuth, Err: errors.New("forbidden")}, ExitAuth, "auth"},
+		{"should map missing zones", fmt.Errorf("%w: example.com", client.ErrZoneNotFound), ExitZoneNotFound, "zone_not_found"},
+		{"should map conflicts", &client.APIError{Class: client.ErrConflict, Err: errors.New("exists")}, ExitConflict, "conflict"},
+		{"should map rate limits", fmt.Errorf("failed to update record: %w", &client.APIError{Class: client.ErrRateLimited, Err: errors.New("slow down")}), ExitRateLimited, "rate_limited"},
+		{"should map server errors", &client.APIError{Class: client.ErrServer, Err: errors.New("bad gateway")}, ExitServerError, "server_error"},
+		{"should map missing records", client.ErrRecordNotFound, ExitFailure, "record_not_found"},
+		{"should map records that disappeared", fmt.Errorf("failed to update DNS record: %w", &client.APIError{Class: client.ErrRecordNotFound, StatusCode: 404, Err: errors.New("record does not exist")}), ExitFailure, "record_not_found"},
+		{"should map timeouts", fmt.Errorf("failed to create record: %w", context.DeadlineExceeded), ExitTimeout, "timeout"},
+		{"should map timeouts while retrying server errors", &client.APIError{Class: client.ErrServer, StatusCode: 502, Err: fmt.Errorf("%w (last error: %w)", context.DeadlineExceeded, errors.New("bad gateway"))}, ExitTimeout, "timeout"},
+		{"should map cancellation", fmt.Errorf("stopped before applying all changes: %w", context.Canceled), ExitCanceled, "canceled"},
+		{"should fall back to a general failure", errors.New("unexpected"), ExitFailure, "failure"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ExitCode(test.err); got != test.exitCode {
+				t.Errorf("ExitCode() is incorrect, got: %d, want: %d", got, test.exitCode)
+			}
+			if got := ErrorCode(test.err); got != test.code {
+				t.Errorf("ErrorCode() is incorrect, got: %q, want: %q", got, test.code)
+			}
+		})
+	}
+}
+
+func TestExitCodesMatchReadme(t *testing.T) {
+	readme, err := os.ReadFile("../../README.md")
+	if err != nil {
+		t.Fatalf("Failed to read README: %v", err)
+	}
+
+	documented := make(map[string]int)
+	for _, line := range strings.Split(string(readme), "\n") {
+		var exitCode int
+		var code string
+		if _, err := fmt.Sscanf(line, "| `%d` | `%s", &exitCode, &code); err == nil {
+			documented[strings.TrimSuffix(code, "`")] = exitCode
+		}
+	}
+
+	mapped := map[string]int{"failure": ExitFailure}
+	for code, exitCode := range codeExitCodes {
+		mapped[code] = exitCode
+	}
+	for _, sentinel := range sentinelErrors {
+		mapped[sentinel.code] = sentinel.exitCode
+	}
+
+	for code, exitCode := range mapped {
+		if got, ok := documented[code]; !ok || got != exitCode {
+			t.Errorf("README documents %q with exit code %d, want: %d", code, got, exitCode)
+		}
+	}
+	if len(documented) != len(mapped) {
+		t.Errorf("README documents %d error codes, want: %d", len(documented), len(mapped))
+	}
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+var WriteGitHubOutputs = writeGitHubOutputs
+
+// writeGitHubOutputs appends the outputs to the file named by GITHUB_OUTPUT,
+// so later steps of the workflow can read them. It does nothing outside of
+// GitHub Actions.
+func writeGitHubOutputs(outputs map[string]string) error {
+	path := os.Getenv("GITHUB_OUTPUT")
+	if path == "" {
+		return nil
+	}
+
+	keys := make([]string, 0, len(outputs))
+	for key := range outputs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var content strings.Builder
+	for _, key := range keys {
+		value := outputs[key]
+		if !strings.ContainsAny(value, "\r\n") {
+			fmt.Fprintf(&content, "%s=%s\n", key, value)
+			continue
+		}
+
+		// Multiline values use the heredoc syntax with a delimiter that
+		// cannot appear in the value
+		delimiter, err := outputDelimiter()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&content, "%s<<%s\n%s\n%s\n", key, delimiter, value, delimiter)
+	}
+
+	return appendToFile(path, "GITHUB_OUTPUT", content.String())
+}
+
+var WriteGitHubStepSummary = writeGitHubStepSummary
+
+// writeGitHubStepSummary appends Markdown to the file named by
+// GITHUB_STEP_SUMMARY, which is shown on the workflow run page. It does
+// nothing outside of GitHub Actions.
+func writeGitHubStepSummary(markdown string) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return nil
+	}
+
+	return appendToFile(path, "GITHUB_STEP_SUMMARY", markdown)
+}
+
+// appendToFile appends content to one of the files GitHub Actions reads
+// after the step, named by the given environment variable
+func appendToFile(path, name, content string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(content); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// outputDelimiter returns a random heredoc delimiter for multiline outputs
+func outputDelimiter() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate output delimiter: %w", err)
+	}
+	return "ghadelimiter_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteGitHubOutputs(t *testing.T) {
+	t.Run("should append outputs to GITHUB_OUTPUT", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "output")
+		if err := os.WriteFile(path, []byte("existing=value\n"), 0o644); err != nil {
+			t.Fatal("Failed to create output file:", err)
+		}
+		t.Setenv("GITHUB_OUTPUT", path)
+
+		err := WriteGitHubOutputs(map[string]string{
+			"record_id": "abc123",
+			"operation": "created",
+		})
+		if err != nil {
+			t.Fatalf("WriteGitHubOutputs() returned an error: %v", err)
+		}
+
+		content, _ := os.ReadFile(path)
+		expected := "existing=value\noperation=created\nrecord_id=abc123\n"
+		if string(content) != expected {
+			t.Errorf("Unexpected output file, got: %q, want: %q", content, expected)
+		}
+	})
+
+	t.Run("should use a delimiter for multiline values", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "output")
+		t.Setenv("GITHUB_OUTPUT", path)
+
+		if err := WriteGitHubOutputs(map[string]string{"previous_content": "line1\nline2"}); err != nil {
+			t.Fatalf("WriteGitHubOutputs() returned an error: %v", err)
+		}
+
+		content, _ := os.ReadFile(path)
+		lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+		if len(lines) != 4 || !strings.HasPrefix(lines[0], "previous_content<<ghadelimiter_") {
+			t.Fatalf("Unexpected output file: %q", content)
+		}
+		if lines[3] != strings.TrimPrefix(lines[0], "previous_content<<") {
+			t.Errorf("Delimiters do not match: %q", content)
+		}
+	})
+
+	t.Run("should do nothing outside of GitHub Actions", func(t *testing.T) {
+		t.Setenv("GITHUB_OUTPUT", "")
+
+		if err := WriteGitHubOutputs(map[string]string{"operation": "created"}); err != nil {
+			t.Errorf("WriteGitHubOutputs() returned an error: %v", err)
+		}
+	})
+}
+
+func TestWriteGitHubStepSummary(t *testing.T) {
+	t.Run("should append to GITHUB_STEP_SUMMARY", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "summary")
+		t.Setenv("GITHUB_STEP_SUMMARY", path)
+
+		for _, markdown := range []string{"# First\n", "# Second\n"} {
+			if err := WriteGitHubStepSummary(markdown); err != nil {
+				t.Fatalf("WriteGitHubStepSummary() returned an error: %v", err)
+			}
+		}
+
+		content, _ := os.ReadFile(path)
+		if string(content) != "# First\n# Second\n" {
+			t.Errorf("Unexpected summary file: %q", content)
+		}
+	})
+
+	t.Run("should do nothing outside of GitHub Actions", func(t *testing.T) {
+		t.Setenv("GITHUB_STEP_SUMMARY", "")
+
+		if err := WriteGitHubStepSummary("# Summary\n"); err != nil {
+			t.Errorf("WriteGitHubStepSummary() returned an error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// inputKind describes how the value of an action input is validated and
+// turned into a command-line argument
+type inputKind int
+
+const (
+	stringInput inputKind = iota
+	boolInput
+	intInput
+	floatInput
+	durationInput
+)
+
+// actionInput maps a GitHub Actions input to the flag it sets
+type actionInput struct {
+	name  string
+	flag  string
+	short string
+	kind  inputKind
+}
+
+// actionInputs lists the inputs read from INPUT_* environment variables, in
+// the order their arguments are built
+var actionInputs = []actionInput{
+	{name: "record", flag: "--record", short: "-r", kind: stringInput},
+	{name: "zone_name", flag: "--zone-name", short: "-z", kind: stringInput},
+	{name: "delete", flag: "--delete", short: "-d", kind: boolInput},
+	{name: "dry_run", flag: "--dry-run", kind: boolInput},
+	{name: "skip_preflight", flag: "--skip-preflight", kind: boolInput},
+	{name: "type", flag: "--type", short: "-y", kind: stringInput},
+	{name: "target", flag: "--target", short: "-t", kind: stringInput},
+	{name: "proxy", flag: "--proxy", short: "-p", kind: boolInput},
+	{name: "ttl", flag: "--ttl", short: "-l", kind: floatInput},
+	{name: "priority", flag: "--priority", kind: intInput},
+	{name: "service", flag: "--service", kind: stringInput},
+	{name: "proto", flag: "--proto", kind: stringInput},
+	{name: "weight", flag: "--weight", kind: intInput},
+	{name: "port", flag: "--port", kind: intInput},
+	{name: "caa_flags", flag: "--caa-flags", kind: intInput},
+	{name: "caa_tag", flag: "--caa-tag", kind: stringInput},
+	{name: "timeout", flag: "--timeout", kind: durationInput},
+}
+
+var InputArgs = inputArgs
+
+// inputArgs converts the GitHub Actions inputs found in INPUT_* environment
+// variables into command-line arguments. Values are passed as single
+// arguments, so spaces and shell metacharacters are kept as they are. Empty
+// inputs are skipped, as GitHub sets a variable for every declared input.
+func inputArgs() ([]string, error) {
+	var args []string
+
+	for _, input := range actionInputs {
+		value := strings.TrimSpace(os.Getenv(inputVariable(input.name)))
+		if value == "" {
+			continue
+		}
+
+		switch input.kind {
+		case boolInput:
+			enabled, err := parseBoolInput(input.name, value)
+			if err != nil {
+				return nil, err
+			}
+			if enabled {
+				args = append(args, input.flag)
+			}
+			continue
+		case intInput:
+			if _, err := strconv.Atoi(value); err != nil {
+				return nil, fmt.Errorf("input %s must be an integer, got %q", input.name, value)
+			}
+		case floatInput:
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return nil, fmt.Errorf("input %s must be a number, got %q", input.name, value)
+			}
+		case durationInput:
+			if _, err := time.ParseDuration(value); err != nil {
+				return nil, fmt.Errorf("input %s must be a duration such as 90s or 5m, got %q", input.name, value)
+			}
+		}
+
+		args = append(args, input.flag+"="+value)
+	}
+
+	if manifest := strings.TrimSpace(os.Getenv(inputVariable("manifest"))); manifest != "" {
+		args = append(args, "apply", "--file="+manifest)
+	}
+
+	return args, nil
+}
+
+// withoutOverriddenInputs drops the input arguments whose flag is also given
+// on the command line, so that the command line replaces the input instead
+// of adding to it, as with repeated --target flags or an enabled --proxy
+func withoutOverriddenInputs(inputs, cmdline []string) []string {
+	given := make(map[string]bool)
+	for _, arg := range cmdline {
+		name, _, _ := strings.Cut(arg, "=")
+		given[name] = true
+	}
+
+	var args []string
+	for _, arg := range inputs {
+		name, _, _ := strings.Cut(arg, "=")
+		if input, ok := inputForFlag(name); ok && (given[input.flag] || (input.short != "" && given[input.short])) {
+			continue
+		}
+		args = append(args, arg)
+	}
+	return args
+}
+
+// inputForFlag returns the input setting the given long flag
+func inputForFlag(flag string) (actionInput, bool) {
+	for _, input := range actionInputs {
+		if input.flag == flag {
+			return input, true
+		}
+	}
+	return actionInput{}, false
+}
+
+// inputVariable returns the environment variable holding an action input
+func inputVariable(name string) string {
+	return "INPUT_" + strings.ToUpper(name)
+}
+
+// parseBoolInput parses a boolean input with the YAML 1.2 core schema rules
+// used by GitHub Actions, rejecting anything else instead of guessing
+func parseBoolInput(name, value string) (bool, error) {
+	switch value {
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	return false, fmt.Errorf("input %s must be one of true, True, TRUE, false, False or FALSE, got %q", name, value)
+}
@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearInputs unsets every INPUT_* variable for the duration of the test
+func clearInputs(t *testing.T) {
+	for _, input := range append(actionInputs, actionInput{name: "manifest"}) {
+		t.Setenv(inputVariable(input.name), "")
+	}
+}
+
+func TestInputArgs(t *testing.T) {
+	t.Run("should convert inputs to arguments", func(t *testing.T) {
+		clearInputs(t)
+		t.Setenv("INPUT_RECORD", "www.example.com")
+		t.Setenv("INPUT_ZONE_NAME", "example.com")
+		t.Setenv("INPUT_TYPE", "TXT")
+		t.Setenv("INPUT_TARGET", `v=spf1 include:_spf.example.com -all; echo "$(id)"`)
+		t.Setenv("INPUT_PROXY", "false")
+		t.Setenv("INPUT_DELETE", "False")
+		t.Setenv("INPUT_DRY_RUN", "TRUE")
+		t.Setenv("INPUT_SKIP_PREFLIGHT", "true")
+		t.Setenv("INPUT_TTL", "300")
+
+		args, err := InputArgs()
+		if err != nil {
+			t.Fatalf("InputArgs() returned an error: %v", err)
+		}
+
+		expected := []string{
+			"--record=www.example.com",
+			"--zone-name=example.com",
+			"--dry-run",
+			"--skip-preflight",
+			"--type=TXT",
+			`--target=v=spf1 include:_spf.example.com -all; echo "$(id)"`,
+			"--ttl=300",
+		}
+		if !reflect.DeepEqual(args, expected) {
+			t.Errorf("InputArgs() is incorrect, got: %q, want: %q", args, expected)
+		}
+	})
+
+	t.Run("should add the apply subcommand for a manifest", func(t *testing.T) {
+		clearInputs(t)
+		t.Setenv("INPUT_MANIFEST", "dns/records.yaml")
+
+		args, err := InputArgs()
+		if err != nil {
+			t.Fatalf("InputArgs() returned an error: %v", err)
+		}
+		if !reflect.DeepEqual(args, []string{"apply", "--file=dns/records.yaml"}) {
+			t.Errorf("InputArgs() is incorrect, got: %q", args)
+		}
+	})
+
+	t.Run("should reject booleans outside of the YAML core schema", func(t *testing.T) {
+		clearInputs(t)
+		t.Setenv("INPUT_PROXY", "yes")
+
+		_, err := InputArgs()
+		if err == nil || !strings.Contains(err.Error(), "input proxy") {
+			t.Errorf("Expected an error for input proxy, got: %v", err)
+		}
+	})
+
+	t.Run("should reject non-numeric values", func(t *testing.T) {
+		clearInputs(t)
+		t.Setenv("INPUT_PRIORITY", "ten")
+
+		_, err := InputArgs()
+		if err == nil || !strings.Contains(err.Error(), "input priority must be an integer") {
+			t.Errorf("Expected an error for input priority, got: %v", err)
+		}
+	})
+
+	t.Run("should reject invalid durations", func(t *testing.T) {
+		clearInputs(t)
+		t.Setenv("INPUT_TIMEOUT", "5 minutes")
+
+		_, err := InputArgs()
+		if err == nil || !strings.Contains(err.Error(), "input timeout must be a duration") {
+			t.Errorf("Expected an error for input timeout, got: %v", err)
+		}
+	})
+}
+
+func TestParseArgsFromInputs(t *testing.T) {
+	clearInputs(t)
+	t.Setenv("INPUT_RECORD", "www.example.com")
+	t.Setenv("INPUT_ZONE_NAME", "example.com")
+	t.Setenv("INPUT_TYPE", "CNAME")
+	t.Setenv("INPUT_TARGET", "example.net")
+	t.Setenv("INPUT_PROXY", "true")
+	t.Setenv("INPUT_TIMEOUT", "90s")
+
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+	os.Args = []string{"yaca", "--type", "A", "--target", "192.0.2.1"}
+
+	args := ParseArgs()
+
+	if args.Record != "www.example.com" || args.ZoneName != "example.com" || !args.Proxy {
+		t.Errorf("Inputs were not applied, got: %+v", args)
+	}
+	if args.Timeout != 90*time.Second {
+		t.Errorf("Expected the timeout input to be applied, got: %s", args.Timeout)
+	}
+	if args.Type != "A" {
+		t.Errorf("Expected the command line to override the type input, got: %s", args.Type)
+	}
+	if len(args.Targets) != 1 || args.Target != "192.0.2.1" {
+		t.Errorf("Expected the command line to replace the target input, got: %q", args.Targets)
+	}
+}
+
+func TestParseArgsOverridesBooleanInputs(t *testing.T) {
+	clearInputs(t)
+	t.Setenv("INPUT_PROXY", "true")
+	t.Setenv("INPUT_TARGET", "example.net")
+
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+	os.Args = []string{"yaca", "--proxy=false", "-t", "192.0.2.1"}
+
+	args := ParseArgs()
+
+	if args.Proxy {
+		t.Errorf("Expected --proxy=false to turn off the proxy input")
+	}
+	if args.Target != "192.0.2.1" {
+		t.Errorf("Expected the short flag to replace the target input, got: %s", args.Target)
+	}
+}
@@ -38,7 +38,11 @@ func loadEnv() error {
 		// Log that sensitive environment variables are set (without values)
 		logger.Debug("Environment check",
 			slog.Bool("has_cloudflare_email", os.Getenv("CLOUDFLARE_API_EMAIL") != ""),
-			slog.Bool("has_cloudflare_token", os.Getenv("CLOUDFLARE_API_TOKEN") != ""))
+			slog.Bool("has_cloudflare_token", os.Getenv("CLOUDFLARE_API_TOKEN") != ""),
+			slog.Bool("has_cloudflare_key", os.Getenv("CLOUDFLARE_API_KEY") != ""),
+			slog.Bool("has_cloudflare_email_file", os.Getenv("CLOUDFLARE_API_EMAIL_FILE") != ""),
+			slog.Bool("has_cloudflare_token_file", os.Getenv("CLOUDFLARE_API_TOKEN_FILE") != ""),
+			slog.Bool("has_cloudflare_key_file", os.Getenv("CLOUDFLARE_API_KEY_FILE") != ""))
 	}
 
 	return nil
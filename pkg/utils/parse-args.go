@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"fmt"
 	"os"
+	"strings"
 	"yaca/models"
 
 	"github.com/alexflint/go-arg"
@@ -16,7 +18,18 @@ func parseArgs() (models.Args) {
 		// This error should not happen with empty config and valid args struct
 		panic(err)
 	}
-	err = p.Parse(os.Args[1:])
+	// GitHub Actions inputs are dropped when the same flag is given on the
+	// command line, so explicit flags take precedence
+	inputs, err := InputArgs()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	inputs = withoutOverriddenInputs(inputs, os.Args[1:])
+	if hasSubcommand(os.Args[1:]) {
+		inputs = withoutSubcommand(inputs)
+	}
+	err = p.Parse(append(inputs, os.Args[1:]...))
 	if err != nil {
 		// Only exit if there's a real parsing error, not just empty args
 		if err == arg.ErrHelp {
@@ -26,5 +39,43 @@ func parseArgs() (models.Args) {
 		p.WriteHelp(os.Stderr)
 		os.Exit(1)
 	}
+	args.Target = strings.Join(args.Targets, ",")
+	return args
+}
+
+// SplitTargets splits a comma-separated list of targets, trimming whitespace
+func SplitTargets(target string) []string {
+	targets := strings.Split(target, ",")
+	for i, value := range targets {
+		targets[i] = strings.TrimSpace(value)
+	}
+	return targets
+}
+
+// subcommands lists the subcommands accepted on the command line
+var subcommands = map[string]bool{
+	"apply":        true,
+	"plan":         true,
+	"verify-token": true,
+}
+
+// hasSubcommand reports whether the command-line arguments name a subcommand
+func hasSubcommand(args []string) bool {
+	for _, arg := range args {
+		if subcommands[arg] {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutSubcommand drops the subcommand added by the manifest input, so that
+// a subcommand given on the command line is used instead
+func withoutSubcommand(args []string) []string {
+	for i, arg := range args {
+		if subcommands[arg] {
+			return args[:i]
+		}
+	}
 	return args
 }
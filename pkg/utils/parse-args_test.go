@@ -54,3 +54,39 @@ func TestParseArgs(t *testing.T) {
 		}
 	})
 }
+
+func TestParseArgsRepeatedTarget(t *testing.T) {
+	originalArgs := os.Args
+	defer func() { os.Args = originalArgs }()
+	os.Args = []string{
+		"yaca",
+		"-r", "www.example.com",
+		"-z", "example.com",
+		"-y", "A",
+		"-t", "192.168.1.1",
+		"--target", "192.168.1.2,192.168.1.3",
+	}
+
+	args := ParseArgs()
+
+	if args.Target != "192.168.1.1,192.168.1.2,192.168.1.3" {
+		t.Errorf("Target is incorrect, got: %s", args.Target)
+	}
+	if len(args.Targets) != 2 {
+		t.Errorf("Targets is incorrect, got: %v", args.Targets)
+	}
+}
+
+func TestSplitTargets(t *testing.T) {
+	targets := SplitTargets("ns1.example.net, ns2.example.net ,ns3.example.net")
+	expected := []string{"ns1.example.net", "ns2.example.net", "ns3.example.net"}
+
+	if len(targets) != len(expected) {
+		t.Fatalf("SplitTargets() returned %d targets, want %d", len(targets), len(expected))
+	}
+	for i := range expected {
+		if targets[i] != expected[i] {
+			t.Errorf("Target %d is incorrect, got: %s, want: %s", i, targets[i], expected[i])
+		}
+	}
+}
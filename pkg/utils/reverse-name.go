@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// ReverseName returns the reverse DNS name of an IP address, e.g.
+// 4.3.2.1.in-addr.arpa for 1.2.3.4, or the nibble format under ip6.arpa
+// for IPv6 addresses
+func ReverseName(ip string) (string, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return "", fmt.Errorf("invalid IP address: %s", ip)
+	}
+
+	if v4 := parsed.To4(); v4 != nil && !strings.Contains(ip, ":") {
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa", v4[3], v4[2], v4[1], v4[0]), nil
+	}
+
+	v6 := parsed.To16()
+	nibbles := make([]string, 0, 2*len(v6))
+	for i := len(v6) - 1; i >= 0; i-- {
+		nibbles = append(nibbles, string(hexDigits[v6[i]&0x0f]), string(hexDigits[v6[i]>>4]))
+	}
+
+	return strings.Join(nibbles, ".") + ".ip6.arpa", nil
+}
+
+// IsWithinZone reports whether name is the zone apex or a subdomain of zone
+func IsWithinZone(name, zone string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	zone = strings.ToLower(strings.TrimSuffix(zone, "."))
+	return name == zone || strings.HasSuffix(name, "."+zone)
+}
@@ -0,0 +1,44 @@
+package utils
+
+import "testing"
+
+func TestReverseName(t *testing.T) {
+	t.Run("should compute IPv4 reverse name", func(t *testing.T) {
+		name, err := ReverseName("1.2.3.4")
+		if err != nil {
+			t.Fatalf("ReverseName() returned an error: %v", err)
+		}
+		if name != "4.3.2.1.in-addr.arpa" {
+			t.Errorf("ReverseName() = %s, want %s", name, "4.3.2.1.in-addr.arpa")
+		}
+	})
+
+	t.Run("should compute IPv6 reverse name in nibble format", func(t *testing.T) {
+		name, err := ReverseName("2001:db8::567:89ab")
+		if err != nil {
+			t.Fatalf("ReverseName() returned an error: %v", err)
+		}
+		want := "b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa"
+		if name != want {
+			t.Errorf("ReverseName() = %s, want %s", name, want)
+		}
+	})
+
+	t.Run("should return error for invalid IP", func(t *testing.T) {
+		if _, err := ReverseName("not-an-ip"); err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+}
+
+func TestIsWithinZone(t *testing.T) {
+	if !IsWithinZone("4.3.2.1.in-addr.arpa", "2.1.in-addr.arpa") {
+		t.Error("Expected name to be within zone")
+	}
+	if !IsWithinZone("2.1.in-addr.arpa.", "2.1.IN-ADDR.ARPA") {
+		t.Error("Expected zone apex to be within zone")
+	}
+	if IsWithinZone("4.3.2.1.in-addr.arpa", "12.1.in-addr.arpa") {
+		t.Error("Expected name not to be within zone")
+	}
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"net"
 	"strings"
 )
 
@@ -39,3 +40,11 @@ func IsIPAddress(s string) bool {
 	
 	return true
 }
+
+// IsIPv6Address checks if a string is a valid IPv6 address
+func IsIPv6Address(s string) bool {
+	if !strings.Contains(s, ":") {
+		return false
+	}
+	return net.ParseIP(s) != nil
+}
@@ -2,12 +2,28 @@ package utils
 
 import (
 	"fmt"
+	"strings"
 
 	"yaca/models"
 )
 
 var ValidateArgs = validateArgs
 
+// caaTags lists the property tags accepted for CAA records
+var caaTags = map[string]bool{
+	"issue":     true,
+	"issuewild": true,
+	"iodef":     true,
+}
+
+// srvProtocols lists the protocols accepted for SRV records
+var srvProtocols = map[string]bool{
+	"tcp":  true,
+	"udp":  true,
+	"tls":  true,
+	"sctp": true,
+}
+
 func validateArgs(args *models.Args) error {
 	if args.Record == "" {
 		return fmt.Errorf("record is required")
@@ -17,10 +33,7 @@ func validateArgs(args *models.Args) error {
 	}
 
 	if args.Delete {
-		if args.Target != "" || args.Type != "" || args.Proxy || args.Ttl != 0 {
-			return fmt.Errorf("all the arguments, except for record and zone name, must be empty when delete is true")
-		}
-		return nil
+		return validateDeleteArgs(args)
 	}
 
 	if args.Target == "" {
@@ -30,5 +43,198 @@ func validateArgs(args *models.Args) error {
 		return fmt.Errorf("type is required")
 	}
 
+	if len(args.Targets) > 1 && !models.IsRecordSetType(args.Type) {
+		return fmt.Errorf("target can only be repeated for A, AAAA and NS records")
+	}
+	if models.IsRecordSetType(args.Type) {
+		for _, target := range SplitTargets(args.Target) {
+			if target == "" {
+				return fmt.Errorf("target contains an empty value")
+			}
+			if args.Type == "AAAA" && !IsIPv6Address(target) {
+				return fmt.Errorf("target must be a valid IPv6 address for AAAA records")
+			}
+		}
+	}
+	if args.Type == "TXT" && args.Proxy {
+		return fmt.Errorf("proxy cannot be enabled for TXT records")
+	}
+
+	if err := validateUint16("priority", args.Priority); err != nil {
+		return err
+	}
+	if args.Type == "MX" {
+		if args.Priority == nil {
+			return fmt.Errorf("priority is required for MX records")
+		}
+		if IsIPAddress(args.Target) || IsIPv6Address(args.Target) {
+			return fmt.Errorf("target must be a hostname, not an IP address, for MX records")
+		}
+	}
+
+	if args.Type != "SRV" && hasSRVArgs(args) {
+		return fmt.Errorf("service, proto, weight and port are only valid for SRV records")
+	}
+	if args.Type != "CAA" && hasCAAArgs(args) {
+		return fmt.Errorf("caa-flags and caa-tag are only valid for CAA records")
+	}
+
+	switch args.Type {
+	case "SRV":
+		return validateSRVArgs(args)
+	case "CAA":
+		return validateCAAArgs(args)
+	case "NS":
+		return validateNSArgs(args)
+	case "PTR":
+		return validatePTRArgs(args)
+	case "HTTPS", "SVCB":
+		if args.Proxy {
+			return fmt.Errorf("proxy cannot be enabled for %s records", args.Type)
+		}
+		if _, err := models.ParseSVCB(args.Target); err != nil {
+			return fmt.Errorf("invalid %s target: %w", args.Type, err)
+		}
+	}
+
+	return nil
+}
+
+// validateDeleteArgs validates the arguments identifying the records to
+// delete: the record name, with the full owner name for SRV records, and
+// optionally the type and target. CAA records can also be matched on their
+// tag and flags, and SRV records on their weight and port.
+func validateDeleteArgs(args *models.Args) error {
+	if args.Proxy || args.Priority != nil || args.Service != "" || args.Proto != "" ||
+		(args.Type != "SRV" && (args.Weight != nil || args.Port != nil)) ||
+		(args.Type != "CAA" && hasCAAArgs(args)) {
+		return fmt.Errorf("all the arguments, except for record, zone name, type and target, must be empty when delete is true; caa-tag and caa-flags may also be set for CAA records, and weight and port for SRV records")
+	}
+	if args.Target != "" && args.Type == "" {
+		return fmt.Errorf("type is required to delete a record by target")
+	}
+	if args.CAATag != "" && !caaTags[strings.ToLower(args.CAATag)] {
+		return fmt.Errorf("caa-tag must be one of issue, issuewild or iodef for CAA records")
+	}
+	return nil
+}
+
+// validateCAAArgs validates the structured fields of a CAA record
+func validateCAAArgs(args *models.Args) error {
+	tag := strings.ToLower(args.CAATag)
+	if !caaTags[tag] {
+		return fmt.Errorf("caa-tag must be one of issue, issuewild or iodef for CAA records")
+	}
+	if args.CAAFlags != nil && (*args.CAAFlags < 0 || *args.CAAFlags > 255) {
+		return fmt.Errorf("caa-flags must be between 0 and 255")
+	}
+	if tag == "iodef" && !hasAnyPrefix(args.Target, "mailto:", "http://", "https://") {
+		return fmt.Errorf("target must be a mailto: or http(s):// URL for iodef CAA records")
+	}
+	if args.Proxy {
+		return fmt.Errorf("proxy cannot be enabled for CAA records")
+	}
+
+	return nil
+}
+
+// validateSRVArgs validates the structured fields of an SRV record
+func validateSRVArgs(args *models.Args) error {
+	if strings.TrimPrefix(args.Service, "_") == "" {
+		return fmt.Errorf("service is required for SRV records")
+	}
+	if !srvProtocols[strings.ToLower(strings.TrimPrefix(args.Proto, "_"))] {
+		return fmt.Errorf("proto must be one of tcp, udp, tls or sctp for SRV records")
+	}
+	if args.Priority == nil {
+		return fmt.Errorf("priority is required for SRV records")
+	}
+	if args.Port == nil {
+		return fmt.Errorf("port is required for SRV records")
+	}
+	if err := validateUint16("port", args.Port); err != nil {
+		return err
+	}
+	if err := validateUint16("weight", args.Weight); err != nil {
+		return err
+	}
+	if IsIPAddress(args.Target) || IsIPv6Address(args.Target) {
+		return fmt.Errorf("target must be a hostname, not an IP address, for SRV records")
+	}
+	if args.Proxy {
+		return fmt.Errorf("proxy cannot be enabled for SRV records")
+	}
+
+	return nil
+}
+
+// hasSRVArgs reports whether any of the SRV-specific arguments is set
+func hasSRVArgs(args *models.Args) bool {
+	return args.Service != "" || args.Proto != "" || args.Weight != nil || args.Port != nil
+}
+
+// validateNSArgs validates the nameservers of an NS delegation
+func validateNSArgs(args *models.Args) error {
+	if args.Proxy {
+		return fmt.Errorf("proxy cannot be enabled for NS records")
+	}
+	if strings.EqualFold(strings.TrimSuffix(args.Record, "."), strings.TrimSuffix(args.ZoneName, ".")) {
+		return fmt.Errorf("NS records at the zone apex are managed by Cloudflare")
+	}
+	for _, target := range SplitTargets(args.Target) {
+		if IsIPAddress(target) || IsIPv6Address(target) {
+			return fmt.Errorf("target must contain hostnames, not IP addresses, for NS records")
+		}
+	}
+
+	return nil
+}
+
+// validatePTRArgs validates a PTR record. When the target is an IP address the
+// reverse name is computed from it and the record name becomes the target.
+func validatePTRArgs(args *models.Args) error {
+	if args.Proxy {
+		return fmt.Errorf("proxy cannot be enabled for PTR records")
+	}
+
+	name, hostname := args.Record, args.Target
+	if IsIPAddress(args.Target) || IsIPv6Address(args.Target) {
+		reverseName, err := ReverseName(args.Target)
+		if err != nil {
+			return err
+		}
+		name, hostname = reverseName, args.Record
+	}
+
+	if IsIPAddress(hostname) || IsIPv6Address(hostname) {
+		return fmt.Errorf("PTR records must point to a hostname, not an IP address")
+	}
+	if !IsWithinZone(name, args.ZoneName) {
+		return fmt.Errorf("reverse name %s is not within zone %s", name, args.ZoneName)
+	}
+
+	return nil
+}
+
+// hasCAAArgs reports whether any of the CAA-specific arguments is set
+func hasCAAArgs(args *models.Args) bool {
+	return args.CAATag != "" || args.CAAFlags != nil
+}
+
+// hasAnyPrefix reports whether s starts with any of the given prefixes
+func hasAnyPrefix(s string, prefixes ...string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateUint16 checks that an optional numeric argument fits in 0-65535
+func validateUint16(name string, value *int) error {
+	if value != nil && (*value < 0 || *value > 65535) {
+		return fmt.Errorf("%s must be between 0 and 65535", name)
+	}
 	return nil
 }
@@ -38,6 +38,56 @@ func TestValidateArgs(t *testing.T) {
 		}
 	})
 
+	t.Run("should return nil when delete is true and only type and target are set", func(t *testing.T) {
+		args := &models.Args{Record: "record", ZoneName: "zone", Delete: true, Type: "TXT", Target: "v=spf1 -all"}
+		err := ValidateArgs(args)
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	})
+
+	t.Run("should return nil when delete is true and CAA tag and flags are set for a CAA record", func(t *testing.T) {
+		flags := 0
+		args := &models.Args{Record: "record", ZoneName: "zone", Delete: true, Type: "CAA", Target: "digicert.com", CAATag: "issue", CAAFlags: &flags}
+		err := ValidateArgs(args)
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	})
+
+	t.Run("should return nil when delete is true and weight and port are set for an SRV record", func(t *testing.T) {
+		weight, port := 5, 5060
+		args := &models.Args{Record: "_sip._tcp.example.com", ZoneName: "zone", Delete: true, Type: "SRV", Target: "sip.example.com", Weight: &weight, Port: &port}
+		err := ValidateArgs(args)
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	})
+
+	t.Run("should return error when delete is true and service is set", func(t *testing.T) {
+		args := &models.Args{Record: "example.com", ZoneName: "zone", Delete: true, Type: "SRV", Service: "sip"}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return error when delete is true and CAA tag is set for another type", func(t *testing.T) {
+		args := &models.Args{Record: "record", ZoneName: "zone", Delete: true, Type: "TXT", CAATag: "issue"}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return error when delete is true and proxy is set", func(t *testing.T) {
+		args := &models.Args{Record: "record", ZoneName: "zone", Delete: true, Type: "A", Proxy: true}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
 	t.Run("should return error when target is empty", func(t *testing.T) {
 		args := &models.Args{Record: "record", ZoneName: "zone", Target: ""}
 		err := ValidateArgs(args)
@@ -61,4 +111,238 @@ func TestValidateArgs(t *testing.T) {
 			t.Errorf("Expected nil, got %v", err)
 		}
 	})
+	t.Run("should return error when AAAA target is not an IPv6 address", func(t *testing.T) {
+		args := &models.Args{Record: "record", ZoneName: "zone", Target: "192.168.1.1", Type: "AAAA"}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return nil when AAAA target is a valid IPv6 address", func(t *testing.T) {
+		args := &models.Args{Record: "record", ZoneName: "zone", Target: "2001:db8::1", Type: "AAAA"}
+		err := ValidateArgs(args)
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	})
+	t.Run("should return error when proxy is enabled for TXT records", func(t *testing.T) {
+		args := &models.Args{Record: "record", ZoneName: "zone", Target: "v=spf1 -all", Type: "TXT", Proxy: true}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+	t.Run("should return error when priority is out of range", func(t *testing.T) {
+		priority := 65536
+		args := &models.Args{Record: "record", ZoneName: "zone", Target: "mail.example.com", Type: "MX", Priority: &priority}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return error when MX priority is missing", func(t *testing.T) {
+		args := &models.Args{Record: "record", ZoneName: "zone", Target: "mail.example.com", Type: "MX"}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return error when MX target is an IP address", func(t *testing.T) {
+		priority := 10
+		args := &models.Args{Record: "record", ZoneName: "zone", Target: "192.168.1.1", Type: "MX", Priority: &priority}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return nil when MX args are valid", func(t *testing.T) {
+		priority := 0
+		args := &models.Args{Record: "record", ZoneName: "zone", Target: "mail.example.com", Type: "MX", Priority: &priority}
+		err := ValidateArgs(args)
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	})
+	t.Run("should return error when SRV service is missing", func(t *testing.T) {
+		priority, port := 10, 5060
+		args := &models.Args{Record: "example.com", ZoneName: "zone", Target: "sip.example.com", Type: "SRV", Proto: "tcp", Priority: &priority, Port: &port}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return error when SRV proto is invalid", func(t *testing.T) {
+		priority, port := 10, 5060
+		args := &models.Args{Record: "example.com", ZoneName: "zone", Target: "sip.example.com", Type: "SRV", Service: "sip", Proto: "http", Priority: &priority, Port: &port}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return error when SRV port is missing", func(t *testing.T) {
+		priority := 10
+		args := &models.Args{Record: "example.com", ZoneName: "zone", Target: "sip.example.com", Type: "SRV", Service: "sip", Proto: "tcp", Priority: &priority}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return error when SRV weight is out of range", func(t *testing.T) {
+		priority, port, weight := 10, 5060, -1
+		args := &models.Args{Record: "example.com", ZoneName: "zone", Target: "sip.example.com", Type: "SRV", Service: "sip", Proto: "tcp", Priority: &priority, Port: &port, Weight: &weight}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return error when SRV arguments are used with another type", func(t *testing.T) {
+		port := 5060
+		args := &models.Args{Record: "record", ZoneName: "zone", Target: "target", Type: "A", Port: &port}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return nil when SRV args are valid", func(t *testing.T) {
+		priority, port, weight := 10, 5060, 5
+		args := &models.Args{Record: "example.com", ZoneName: "zone", Target: "sip.example.com", Type: "SRV", Service: "sip", Proto: "tcp", Priority: &priority, Port: &port, Weight: &weight}
+		err := ValidateArgs(args)
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	})
+	t.Run("should return error when CAA tag is invalid", func(t *testing.T) {
+		args := &models.Args{Record: "example.com", ZoneName: "zone", Target: "letsencrypt.org", Type: "CAA", CAATag: "policy"}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return error when CAA flags are out of range", func(t *testing.T) {
+		flags := 256
+		args := &models.Args{Record: "example.com", ZoneName: "zone", Target: "letsencrypt.org", Type: "CAA", CAATag: "issue", CAAFlags: &flags}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return error when CAA iodef target is not a URL", func(t *testing.T) {
+		args := &models.Args{Record: "example.com", ZoneName: "zone", Target: "security@example.com", Type: "CAA", CAATag: "iodef"}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return error when CAA arguments are used with another type", func(t *testing.T) {
+		args := &models.Args{Record: "record", ZoneName: "zone", Target: "target", Type: "A", CAATag: "issue"}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return nil when CAA args are valid", func(t *testing.T) {
+		flags := 128
+		args := &models.Args{Record: "example.com", ZoneName: "zone", Target: "letsencrypt.org", Type: "CAA", CAATag: "issuewild", CAAFlags: &flags}
+		err := ValidateArgs(args)
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	})
+	t.Run("should return error when HTTPS target has malformed SvcParams", func(t *testing.T) {
+		args := &models.Args{Record: "example.com", ZoneName: "zone", Target: "1 . alpn=h3 port=abc", Type: "HTTPS"}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return nil when SVCB target is valid", func(t *testing.T) {
+		args := &models.Args{Record: "_dns.example.com", ZoneName: "zone", Target: `1 dns.example.com. alpn="h2,h3" dohpath=/dns-query{?dns}`, Type: "SVCB"}
+		err := ValidateArgs(args)
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	})
+	t.Run("should return error when NS target contains an IP address", func(t *testing.T) {
+		args := &models.Args{Record: "dev.example.com", ZoneName: "example.com", Target: "ns1.example.net,192.168.1.1", Type: "NS"}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return error when NS record is the zone apex", func(t *testing.T) {
+		args := &models.Args{Record: "example.com", ZoneName: "example.com", Target: "ns1.example.net", Type: "NS"}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return nil when NS args are valid", func(t *testing.T) {
+		args := &models.Args{Record: "dev.example.com", ZoneName: "example.com", Target: "ns1.example.net, ns2.example.net", Type: "NS"}
+		err := ValidateArgs(args)
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	})
+	t.Run("should return nil when PTR target is an IP within the reverse zone", func(t *testing.T) {
+		args := &models.Args{Record: "host.example.com", ZoneName: "2.1.in-addr.arpa", Target: "1.2.3.4", Type: "PTR"}
+		err := ValidateArgs(args)
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	})
+
+	t.Run("should return error when computed PTR name is outside the zone", func(t *testing.T) {
+		args := &models.Args{Record: "host.example.com", ZoneName: "3.1.in-addr.arpa", Target: "1.2.3.4", Type: "PTR"}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return nil when PTR record is given as a reverse name", func(t *testing.T) {
+		args := &models.Args{Record: "4.3.2.1.in-addr.arpa", ZoneName: "2.1.in-addr.arpa", Target: "host.example.com", Type: "PTR"}
+		err := ValidateArgs(args)
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	})
+	t.Run("should return nil when A target is a comma-separated set", func(t *testing.T) {
+		args := &models.Args{Record: "www.example.com", ZoneName: "example.com", Target: "192.168.1.1, 192.168.1.2", Type: "A"}
+		err := ValidateArgs(args)
+		if err != nil {
+			t.Errorf("Expected nil, got %v", err)
+		}
+	})
+
+	t.Run("should return error when AAAA set contains an IPv4 address", func(t *testing.T) {
+		args := &models.Args{Record: "www.example.com", ZoneName: "example.com", Target: "2001:db8::1,192.168.1.1", Type: "AAAA"}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
+
+	t.Run("should return error when target is repeated for a single-value type", func(t *testing.T) {
+		args := &models.Args{Record: "www.example.com", ZoneName: "example.com", Target: "a.example.com,b.example.com", Targets: []string{"a.example.com", "b.example.com"}, Type: "CNAME"}
+		err := ValidateArgs(args)
+		if err == nil {
+			t.Error("Expected error, got nil")
+		}
+	})
 }